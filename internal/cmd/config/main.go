@@ -0,0 +1,43 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/1995parham-teaching/fandogh/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// validate loads the configuration which the --config flag gives and prints it without its secrets.
+func validate(cmd *cobra.Command, _ []string) error {
+	path := cmd.Flag(config.Flag).Value.String()
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return fmt.Errorf("configuration is invalid:\n%w", err)
+	}
+
+	_, _ = fmt.Fprintf(cmd.OutOrStdout(), "configuration is valid:\n%+v\n", cfg.Redacted())
+
+	return nil
+}
+
+// Register config command.
+func Register(root *cobra.Command) {
+	// nolint: exhaustruct
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage the configuration",
+	}
+
+	cmd.AddCommand(
+		// nolint: exhaustruct
+		&cobra.Command{
+			Use:          "validate",
+			Short:        "Check the required fields and the production secrets of the configuration",
+			SilenceUsage: true,
+			RunE:         validate,
+		},
+	)
+
+	root.AddCommand(cmd)
+}
@@ -0,0 +1,101 @@
+package gc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/1995parham-teaching/fandogh/internal/cmd/job"
+	"github.com/1995parham-teaching/fandogh/internal/config"
+	"github.com/1995parham-teaching/fandogh/internal/db"
+	"github.com/1995parham-teaching/fandogh/internal/fs"
+	"github.com/1995parham-teaching/fandogh/internal/imaging"
+	"github.com/1995parham-teaching/fandogh/internal/logger"
+	"github.com/1995parham-teaching/fandogh/internal/store/home"
+	"github.com/1995parham-teaching/fandogh/internal/telemetry/trace"
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// DefaultGrace is long enough for the homes which are being created and the direct uploads
+// which are not confirmed yet.
+const DefaultGrace = 24 * time.Hour
+
+func main(logger *zap.Logger, store *home.MongoHome, grace time.Duration, dryRun bool) error {
+	garbage, err := store.Collect(context.Background(), grace, dryRun)
+	if err != nil {
+		return fmt.Errorf("failed to collect the orphaned photos: %w", err)
+	}
+
+	for _, orphan := range garbage.Orphans {
+		logger.Info("orphaned object",
+			zap.String("key", orphan.Key),
+			zap.Int64("size", orphan.Size),
+			zap.Time("last_modified", orphan.LastModified),
+		)
+	}
+
+	for _, missing := range garbage.Missing {
+		logger.Warn("home references a missing object",
+			zap.String("home", missing.Home),
+			zap.String("photo", missing.Photo),
+			zap.String("key", missing.Key),
+		)
+	}
+
+	logger.Info("photos are collected",
+		zap.Bool("dry_run", dryRun),
+		zap.Int("objects", garbage.Objects),
+		zap.Int("orphans", len(garbage.Orphans)),
+		zap.Int("recent", garbage.Recent),
+		zap.Int("deleted", garbage.Deleted),
+		zap.Int("missing", len(garbage.Missing)),
+	)
+
+	return nil
+}
+
+// Register gc command.
+func Register(root *cobra.Command) {
+	var (
+		grace  time.Duration
+		dryRun bool
+	)
+
+	// nolint: exhaustruct
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Remove the orphaned photos and report the homes with the missing photos",
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			if grace < 0 {
+				return fmt.Errorf("%w: --grace %s", home.ErrNegativeGrace, grace)
+			}
+
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			// the usage is printed only for the invalid flags.
+			cmd.SilenceUsage = true
+
+			return job.Run(fx.New(
+				fx.Provide(config.File(cmd.Flag(config.Flag).Value.String())),
+				fx.Provide(logger.Provide),
+				fx.Provide(trace.Provide),
+				fx.Provide(db.Provide),
+				fx.Provide(fs.Provide),
+				fx.Provide(imaging.Provide),
+				fx.Provide(home.Provide),
+				fx.Options(fx.NopLogger),
+				fx.Invoke(func(logger *zap.Logger, store *home.MongoHome) error {
+					return main(logger, store, grace, dryRun)
+				}),
+			))
+		},
+	}
+
+	cmd.Flags().DurationVar(&grace, "grace", DefaultGrace, "only remove the orphaned objects which are older than this")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only report the orphaned objects without removing them")
+
+	root.AddCommand(cmd)
+}
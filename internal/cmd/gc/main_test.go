@@ -0,0 +1,59 @@
+package gc_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/1995parham-teaching/fandogh/internal/cmd/gc"
+	"github.com/1995parham-teaching/fandogh/internal/config"
+	"github.com/1995parham-teaching/fandogh/internal/store/home"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFailure runs the command against a database which is not reachable and with a negative grace period,
+// so the command returns an error, which makes the process exit with a non-zero code.
+func TestFailure(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "config.yml")
+
+	require.NoError(t, os.WriteFile(path, []byte(`
+database:
+  url: mongodb://127.0.0.1:1/?serverSelectionTimeoutMS=100&connectTimeoutMS=100
+`), 0o600))
+
+	cases := []struct {
+		name string
+		args []string
+		err  error
+	}{
+		{name: "Collect", args: []string{"gc", "--dry-run"}, err: nil},
+		{name: "Negative Grace", args: []string{"gc", "--grace=-1h"}, err: home.ErrNegativeGrace},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			// nolint: exhaustruct
+			root := &cobra.Command{Use: "fandogh"}
+			root.PersistentFlags().StringP(config.Flag, "c", "", "configuration file")
+			root.SetOut(io.Discard)
+			root.SetErr(io.Discard)
+
+			gc.Register(root)
+
+			root.SetArgs(append(c.args, "--config", path))
+
+			err := root.Execute()
+			require.Error(t, err)
+
+			if c.err != nil {
+				require.ErrorIs(t, err, c.err)
+			}
+		})
+	}
+}
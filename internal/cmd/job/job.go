@@ -0,0 +1,32 @@
+package job
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/fx"
+)
+
+// Run starts and stops the given application of a command which does its work in an invoked function,
+// so the command fails with the error of that function and the process exits with a non-zero code.
+func Run(app *fx.App) error {
+	if err := app.Err(); err != nil {
+		return fmt.Errorf("command failed: %w", err)
+	}
+
+	start, cancel := context.WithTimeout(context.Background(), app.StartTimeout())
+	defer cancel()
+
+	if err := app.Start(start); err != nil {
+		return fmt.Errorf("command start failed: %w", err)
+	}
+
+	stop, cancel := context.WithTimeout(context.Background(), app.StopTimeout())
+	defer cancel()
+
+	if err := app.Stop(stop); err != nil {
+		return fmt.Errorf("command stop failed: %w", err)
+	}
+
+	return nil
+}
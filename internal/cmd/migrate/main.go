@@ -2,55 +2,156 @@ package migrate
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
 
+	"github.com/1995parham-teaching/fandogh/internal/cmd/job"
 	"github.com/1995parham-teaching/fandogh/internal/config"
 	"github.com/1995parham-teaching/fandogh/internal/db"
 	"github.com/1995parham-teaching/fandogh/internal/logger"
+	"github.com/1995parham-teaching/fandogh/internal/migration"
+	"github.com/1995parham-teaching/fandogh/internal/store/denylist"
+	"github.com/1995parham-teaching/fandogh/internal/store/home"
+	"github.com/1995parham-teaching/fandogh/internal/store/refresh"
 	"github.com/1995parham-teaching/fandogh/internal/store/user"
 	"github.com/spf13/cobra"
-	"go.mongodb.org/mongo-driver/v2/bson"
-	"go.mongodb.org/mongo-driver/v2/mongo"
-	"go.mongodb.org/mongo-driver/v2/mongo/options"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 )
 
-const enable = 1
+// migrations registers the migrations of each store, the configuration is read from the --config flag of the command.
+func migrations(cmd *cobra.Command, invoke any) *fx.App {
+	return fx.New(
+		fx.Provide(config.File(cmd.Flag(config.Flag).Value.String())),
+		fx.Provide(logger.Provide),
+		fx.Provide(db.Provide),
+		migration.Register(user.Migrations),
+		migration.Register(refresh.Migrations),
+		migration.Register(denylist.Migrations),
+		migration.Register(home.Migrations),
+		fx.Provide(migration.Provide),
+		fx.Options(fx.NopLogger),
+		fx.Invoke(invoke),
+	)
+}
+
+func up(logger *zap.Logger, migrator *migration.Migrator) error {
+	applied, err := migrator.Up(context.Background())
+
+	for _, m := range applied {
+		logger.Info("migration is applied", zap.Int64("version", m.Version), zap.String("description", m.Description))
+	}
 
-func main(shutdowner fx.Shutdowner, logger *zap.Logger, db *mongo.Database) {
-	idx, err := db.Collection(user.Collection).Indexes().CreateOne(
-		context.Background(),
-		mongo.IndexModel{
-			Keys:    bson.M{"email": enable},
-			Options: options.Index().SetUnique(true),
-		})
 	if err != nil {
-		logger.Error("failed to create database index", zap.Error(err))
+		return fmt.Errorf("failed to apply migrations: %w", err)
 	}
 
-	logger.Info("database index", zap.Any("index", idx))
+	logger.Info("database is migrated", zap.Int("applied", len(applied)))
+
+	return nil
+}
+
+func down(logger *zap.Logger, migrator *migration.Migrator, steps int) error {
+	reverted, err := migrator.Down(context.Background(), steps)
+
+	for _, m := range reverted {
+		logger.Info("migration is reverted", zap.Int64("version", m.Version), zap.String("description", m.Description))
+	}
 
-	if err := shutdowner.Shutdown(); err != nil {
-		logger.Error("failed to shutdown", zap.Error(err))
+	if err != nil {
+		return fmt.Errorf("failed to revert migrations: %w", err)
 	}
+
+	return nil
+}
+
+func status(migrator *migration.Migrator, out io.Writer) error {
+	statuses, err := migrator.Status(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	_, _ = fmt.Fprintln(w, "VERSION\tAPPLIED AT\tDESCRIPTION")
+
+	for _, s := range statuses {
+		applied := "pending"
+		if s.Applied {
+			applied = s.AppliedAt.Format(time.RFC3339)
+		}
+
+		_, _ = fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, applied, s.Description)
+	}
+
+	_ = w.Flush()
+
+	return nil
 }
 
 // Register migrate command.
 func Register(root *cobra.Command) {
-	root.AddCommand(
+	// nolint: exhaustruct
+	cmd := &cobra.Command{
+		Use:          "migrate",
+		Short:        "Apply the pending database migrations, same as migrate up",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return job.Run(migrations(cmd, up))
+		},
+	}
+
+	steps := 1
+
+	// nolint: exhaustruct
+	downCmd := &cobra.Command{
+		Use:   "down",
+		Short: "Revert the last applied database migrations",
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			if steps < 1 {
+				return fmt.Errorf("%w: --steps %d", migration.ErrInvalidSteps, steps)
+			}
+
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			// the usage is printed only for the invalid flags.
+			cmd.SilenceUsage = true
+
+			return job.Run(migrations(cmd, func(logger *zap.Logger, migrator *migration.Migrator) error {
+				return down(logger, migrator, steps)
+			}))
+		},
+	}
+
+	downCmd.Flags().IntVar(&steps, "steps", steps, "number of the migrations to revert")
+
+	cmd.AddCommand(
 		// nolint: exhaustruct
 		&cobra.Command{
-			Use:   "migrate",
-			Short: "Setup database indices",
-			Run: func(_ *cobra.Command, _ []string) {
-				fx.New(
-					fx.Provide(config.Provide),
-					fx.Provide(logger.Provide),
-					fx.Provide(db.Provide),
-					fx.Options(fx.NopLogger),
-					fx.Invoke(main),
-				).Run()
+			Use:          "up",
+			Short:        "Apply the pending database migrations",
+			SilenceUsage: true,
+			RunE: func(cmd *cobra.Command, _ []string) error {
+				return job.Run(migrations(cmd, up))
 			},
 		},
+		downCmd,
+		// nolint: exhaustruct
+		&cobra.Command{
+			Use:          "status",
+			Short:        "Show the applied and pending database migrations",
+			SilenceUsage: true,
+			RunE: func(cmd *cobra.Command, _ []string) error {
+				return job.Run(migrations(cmd, func(migrator *migration.Migrator) error {
+					return status(migrator, cmd.OutOrStdout())
+				}))
+			},
+		},
+		photosCommand(),
 	)
+
+	root.AddCommand(cmd)
 }
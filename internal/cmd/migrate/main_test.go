@@ -0,0 +1,62 @@
+package migrate_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/1995parham-teaching/fandogh/internal/cmd/migrate"
+	"github.com/1995parham-teaching/fandogh/internal/config"
+	"github.com/1995parham-teaching/fandogh/internal/migration"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFailure runs the commands against a database which is not reachable, so each migration fails
+// and the command returns its error, which makes the process exit with a non-zero code.
+func TestFailure(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "config.yml")
+
+	require.NoError(t, os.WriteFile(path, []byte(`
+database:
+  url: mongodb://127.0.0.1:1/?serverSelectionTimeoutMS=100&connectTimeoutMS=100
+`), 0o600))
+
+	cases := []struct {
+		name string
+		args []string
+		err  error
+	}{
+		{name: "Up", args: []string{"migrate", "up"}, err: nil},
+		{name: "Default", args: []string{"migrate"}, err: nil},
+		{name: "Down", args: []string{"migrate", "down", "--steps", "1"}, err: nil},
+		{name: "Status", args: []string{"migrate", "status"}, err: nil},
+		{name: "Invalid Steps", args: []string{"migrate", "down", "--steps", "-1"}, err: migration.ErrInvalidSteps},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			// nolint: exhaustruct
+			root := &cobra.Command{Use: "fandogh"}
+			root.PersistentFlags().StringP(config.Flag, "c", "", "configuration file")
+			root.SetOut(io.Discard)
+			root.SetErr(io.Discard)
+
+			migrate.Register(root)
+
+			root.SetArgs(append(c.args, "--config", path))
+
+			err := root.Execute()
+			require.Error(t, err)
+
+			if c.err != nil {
+				require.ErrorIs(t, err, c.err)
+			}
+		})
+	}
+}
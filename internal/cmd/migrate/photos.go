@@ -0,0 +1,60 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/1995parham-teaching/fandogh/internal/cmd/job"
+	"github.com/1995parham-teaching/fandogh/internal/config"
+	"github.com/1995parham-teaching/fandogh/internal/db"
+	"github.com/1995parham-teaching/fandogh/internal/fs"
+	"github.com/1995parham-teaching/fandogh/internal/imaging"
+	"github.com/1995parham-teaching/fandogh/internal/logger"
+	"github.com/1995parham-teaching/fandogh/internal/store/home"
+	"github.com/1995parham-teaching/fandogh/internal/telemetry/trace"
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+func photos(logger *zap.Logger, store *home.MongoHome) error {
+	result, err := store.MigrateKeys(context.Background())
+
+	// the missing objects keep their legacy keys.
+	for _, ref := range result.Missing {
+		logger.Warn("legacy object does not exist",
+			zap.String("home", ref.Home), zap.String("photo", ref.Photo), zap.String("key", ref.Key))
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to migrate photo keys after %d homes: %w", result.Migrated, err)
+	}
+
+	logger.Info("photos are moved to the structured keys",
+		zap.Int("homes", result.Migrated), zap.Int("missing", len(result.Missing)))
+
+	return nil
+}
+
+// photosCommand moves the photos with the legacy keys to the structured keys.
+func photosCommand() *cobra.Command {
+	// nolint: exhaustruct
+	return &cobra.Command{
+		Use:          "photos",
+		Short:        "Move the home photos and their thumbnails to the structured storage keys",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return job.Run(fx.New(
+				fx.Provide(config.File(cmd.Flag(config.Flag).Value.String())),
+				fx.Provide(logger.Provide),
+				fx.Provide(trace.Provide),
+				fx.Provide(db.Provide),
+				fx.Provide(fs.Provide),
+				fx.Provide(imaging.Provide),
+				fx.Provide(home.Provide),
+				fx.Options(fx.NopLogger),
+				fx.Invoke(photos),
+			))
+		},
+	}
+}
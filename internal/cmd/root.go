@@ -3,8 +3,11 @@ package cmd
 import (
 	"os"
 
+	"github.com/1995parham-teaching/fandogh/internal/cmd/config"
+	"github.com/1995parham-teaching/fandogh/internal/cmd/gc"
 	"github.com/1995parham-teaching/fandogh/internal/cmd/migrate"
 	"github.com/1995parham-teaching/fandogh/internal/cmd/server"
+	cfg "github.com/1995parham-teaching/fandogh/internal/config"
 	"github.com/spf13/cobra"
 )
 
@@ -20,8 +23,14 @@ func Execute() {
 		Short: "a server with login, user and etc.",
 	}
 
+	root.PersistentFlags().StringP(
+		cfg.Flag, "c", "", "configuration file, "+cfg.DefaultPath+" is read (if it exists) when it is not given",
+	)
+
 	server.Register(root)
 	migrate.Register(root)
+	gc.Register(root)
+	config.Register(root)
 
 	if err := root.Execute(); err != nil {
 		os.Exit(ExitFailure)
@@ -6,9 +6,13 @@ import (
 	"github.com/1995parham-teaching/fandogh/internal/fs"
 	"github.com/1995parham-teaching/fandogh/internal/http/jwt"
 	"github.com/1995parham-teaching/fandogh/internal/http/server"
+	"github.com/1995parham-teaching/fandogh/internal/imaging"
 	"github.com/1995parham-teaching/fandogh/internal/logger"
 	"github.com/1995parham-teaching/fandogh/internal/metric"
+	"github.com/1995parham-teaching/fandogh/internal/security"
+	"github.com/1995parham-teaching/fandogh/internal/store/denylist"
 	"github.com/1995parham-teaching/fandogh/internal/store/home"
+	"github.com/1995parham-teaching/fandogh/internal/store/refresh"
 	"github.com/1995parham-teaching/fandogh/internal/store/user"
 	"github.com/1995parham-teaching/fandogh/internal/telemetry/trace"
 	"github.com/labstack/echo/v5"
@@ -26,6 +30,42 @@ func main(
 	logger.Info("welcome to fandogh server")
 }
 
+// stores provides the stores of the given database driver.
+func stores(driver string) fx.Option {
+	if driver == db.DriverMemory {
+		return fx.Options(
+			fx.Provide(
+				fx.Annotate(user.NewMemoryUser, fx.As(new(user.User))),
+			),
+			fx.Provide(
+				fx.Annotate(home.ProvideMemory, fx.As(new(home.Home))),
+			),
+			fx.Provide(
+				fx.Annotate(refresh.NewMemoryRefresh, fx.As(new(refresh.Refresh))),
+			),
+			fx.Provide(
+				fx.Annotate(denylist.NewMemoryDenylist, fx.As(new(denylist.Denylist))),
+			),
+		)
+	}
+
+	return fx.Options(
+		fx.Provide(db.Provide),
+		fx.Provide(
+			fx.Annotate(user.Provide, fx.As(new(user.User))),
+		),
+		fx.Provide(
+			fx.Annotate(home.Provide, fx.As(new(home.Home))),
+		),
+		fx.Provide(
+			fx.Annotate(refresh.Provide, fx.As(new(refresh.Refresh))),
+		),
+		fx.Provide(
+			fx.Annotate(denylist.Provide, fx.As(new(denylist.Denylist))),
+		),
+	)
+}
+
 // Register server command.
 func Register(root *cobra.Command) {
 	root.AddCommand(
@@ -33,27 +73,40 @@ func Register(root *cobra.Command) {
 		&cobra.Command{
 			Use:   "server",
 			Short: "Run server to serve the requests",
-			Run: func(_ *cobra.Command, _ []string) {
+			RunE: func(cmd *cobra.Command, _ []string) error {
+				// the configuration is read first, because its database driver selects the stores.
+				cfg, err := config.File(cmd.Flag(config.Flag).Value.String())()
+				if err != nil {
+					return err
+				}
+
+				cmd.SilenceUsage = true
+
 				fx.New(
-					fx.Provide(config.Provide),
+					fx.Provide(func() config.Config { return cfg }),
 					fx.Provide(logger.Provide),
 					fx.Provide(trace.Provide),
-					fx.Provide(db.Provide),
 					fx.Provide(fs.Provide),
+					fx.Provide(imaging.Provide),
 					fx.Provide(metric.Provide),
-					fx.Provide(
-						fx.Annotate(user.Provide, fx.As(new(user.User))),
-					),
-					fx.Provide(
-						fx.Annotate(home.Provide, fx.As(new(home.Home))),
-					),
+					fx.Provide(metric.Registerer),
+					fx.Provide(metric.NewHTTP),
+					fx.Provide(metric.NewUser),
+					fx.Provide(metric.NewHome),
+					fx.Provide(security.Provide),
+					stores(cfg.Database.Driver),
 					fx.Provide(jwt.Provide),
 					fx.WithLogger(func(logger *zap.Logger) fxevent.Logger {
 						return &fxevent.ZapLogger{Logger: logger}
 					}),
-					fx.Provide(server.Provide),
+					// the database is not provided with the memory stores.
+					fx.Provide(
+						fx.Annotate(server.Provide, fx.ParamTags("", "", `optional:"true"`)),
+					),
 					fx.Invoke(main),
 				).Run()
+
+				return nil
 			},
 		},
 	)
@@ -1,14 +1,19 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"strings"
 
 	"github.com/1995parham-teaching/fandogh/internal/db"
 	"github.com/1995parham-teaching/fandogh/internal/fs"
 	"github.com/1995parham-teaching/fandogh/internal/http/jwt"
+	"github.com/1995parham-teaching/fandogh/internal/http/server"
+	"github.com/1995parham-teaching/fandogh/internal/imaging"
 	"github.com/1995parham-teaching/fandogh/internal/logger"
 	"github.com/1995parham-teaching/fandogh/internal/metric"
+	"github.com/1995parham-teaching/fandogh/internal/security"
+	"github.com/1995parham-teaching/fandogh/internal/store/home"
 	telemetry "github.com/1995parham-teaching/fandogh/internal/telemetry/config"
 	"github.com/knadh/koanf/v2"
 	"github.com/knadh/koanf/parsers/yaml"
@@ -21,21 +26,62 @@ import (
 const (
 	// Prefix indicates environment variables prefix.
 	Prefix = "fandogh_"
+
+	// DefaultPath is the configuration file which is read when no file is given.
+	DefaultPath = "configs/config.example.yml"
+
+	// Flag is the global flag which gives the configuration file.
+	Flag = "config"
+)
+
+// Environment is where the server runs, the production environment does not accept the default secrets.
+type Environment string
+
+const (
+	Development Environment = "development"
+	Production  Environment = "production"
 )
 
 type Config struct {
 	fx.Out
 
+	Environment Environment      `koanf:"environment"`
 	Database    db.Config        `koanf:"database"`
 	FileStorage fs.Config        `koanf:"file_storage"`
 	Monitoring  metric.Config    `koanf:"monitoring"`
 	Logger      logger.Config    `koanf:"logger"`
 	Telemetry   telemetry.Config `koanf:"telemetry"`
 	JWT         jwt.Config       `koanf:"jwt"`
+	Security    security.Config  `koanf:"security"`
+	Home        home.Config      `koanf:"home"`
+	Imaging     imaging.Config   `koanf:"imaging"`
+	HTTP        server.Config    `koanf:"http"`
+}
+
+// Provide reads configuration from the default configuration file.
+func Provide() (Config, error) {
+	return File("")()
+}
+
+// File returns a provider which reads configuration from the given file, e.g. the one which the --config
+// flag gives. The default configuration file is read when it is empty.
+func File(path string) func() (Config, error) {
+	return func() (Config, error) {
+		instance, err := Load(path)
+		if err != nil {
+			return instance, err
+		}
+
+		log.Printf("following configuration is loaded:\n%+v", instance.Redacted())
+
+		return instance, nil
+	}
 }
 
-// Provide reads configuration with koanf.
-func Provide() Config {
+// Load reads configuration with koanf and validates it. The default configuration file is optional,
+// but the given one must exist. The ${ENV} references are replaced after loading and then the secrets
+// are read from their files.
+func Load(path string) (Config, error) {
 	var instance Config
 
 	k := koanf.New(".")
@@ -43,34 +89,61 @@ func Provide() Config {
 	// load default configuration from file
 	err := k.Load(structs.Provider(Default(), "koanf"), nil)
 	if err != nil {
-		log.Fatalf("error loading default: %s", err)
+		return instance, fmt.Errorf("error loading default: %w", err)
 	}
 
-	// load configuration from our custom config example in here
-	err = k.Load(file.Provider("configs/config.example.yml"), yaml.Parser())
-	if err != nil {
-		log.Printf("error loading config.yml: %s", err)
+	// load configuration from the given file or our custom config example in here
+	if path != "" {
+		if err := k.Load(file.Provider(path), yaml.Parser()); err != nil {
+			return instance, fmt.Errorf("error loading %s: %w", path, err)
+		}
+	} else if err := k.Load(file.Provider(DefaultPath), yaml.Parser()); err != nil {
+		log.Printf("error loading %s: %s", DefaultPath, err)
+	}
+
+	// environment variables are matched with the known keys, because both of them use underscores.
+	keys := make(map[string]string)
+
+	for _, key := range append(k.Keys(), files()...) {
+		keys[strings.ReplaceAll(key, ".", "_")] = key
 	}
 
-	// load environment variables
+	// load environment variables, their prefix is matched case-insensitively.
 	// nolint: exhaustruct
 	err = k.Load(env.Provider(".", env.Opt{
-		Prefix: Prefix,
 		TransformFunc: func(k, v string) (string, any) {
-			return strings.ReplaceAll(strings.ToLower(
-				strings.TrimPrefix(k, Prefix)), "_", "."), v
+			name, ok := strings.CutPrefix(strings.ToLower(k), Prefix)
+			if !ok {
+				return "", nil
+			}
+
+			if key, ok := keys[name]; ok {
+				return key, v
+			}
+
+			return strings.ReplaceAll(name, "_", "."), v
 		},
 	}), nil)
 	if err != nil {
 		log.Printf("error loading environment variables: %s", err)
 	}
 
+	if err := interpolate(k); err != nil {
+		return instance, err
+	}
+
+	if err := resolve(k); err != nil {
+		return instance, err
+	}
+
 	err = k.Unmarshal("", &instance)
 	if err != nil {
-		log.Fatalf("error unmarshalling config: %s", err)
+		return instance, fmt.Errorf("error unmarshalling config: %w", err)
 	}
 
-	log.Printf("following configuration is loaded:\n%+v", instance)
+	if err := instance.Validate(); err != nil {
+		return instance, err
+	}
 
-	return instance
+	return instance, nil
 }
@@ -1,30 +1,53 @@
 package config
 
 import (
+	"time"
+
 	"github.com/1995parham-teaching/fandogh/internal/db"
 	"github.com/1995parham-teaching/fandogh/internal/fs"
 	"github.com/1995parham-teaching/fandogh/internal/http/jwt"
+	"github.com/1995parham-teaching/fandogh/internal/http/server"
+	"github.com/1995parham-teaching/fandogh/internal/imaging"
 	"github.com/1995parham-teaching/fandogh/internal/logger"
 	"github.com/1995parham-teaching/fandogh/internal/metric"
+	"github.com/1995parham-teaching/fandogh/internal/security"
+	"github.com/1995parham-teaching/fandogh/internal/store/home"
 	telemetry "github.com/1995parham-teaching/fandogh/internal/telemetry/config"
 
 	"go.uber.org/fx"
 )
 
+const (
+	refreshTokenTTL = 7 * 24 * time.Hour
+	presignExpiry   = 15 * time.Minute
+	// photo uploads are the largest and the slowest requests.
+	requestTimeout = 2 * time.Minute
+	maxBodyBytes   = 64 << 20
+)
+
 // Default return default configuration.
 func Default() Config {
 	return Config{
-		Out: fx.Out{},
+		Out:         fx.Out{},
+		Environment: Development,
 		Database: db.Config{
-			Name: "fandogh",
-			URL:  "mongodb://127.0.0.1:27017",
+			Driver: db.DriverMongo,
+			Name:   "fandogh",
+			URL:    "mongodb://127.0.0.1:27017",
 		},
 		FileStorage: fs.Config{
-			Endpoint:  "127.0.0.1:9000",
-			AccessKey: "rustfsadmin",
-			SecretKey: "rustfsadmin",
-			UseSSL:    false,
-			Region:    "us-east-1",
+			Driver:        fs.DriverS3,
+			Endpoint:      "127.0.0.1:9000",
+			AccessKey:     "rustfsadmin",
+			SecretKey:     "rustfsadmin",
+			UseSSL:        false,
+			Region:        "us-east-1",
+			PresignExpiry: presignExpiry,
+			Local: fs.LocalConfig{
+				Root:   "data",
+				URL:    "http://127.0.0.1:1378/files",
+				Secret: "secret",
+			},
 		},
 		Monitoring: metric.Config{
 			Address: ":8080",
@@ -41,6 +64,45 @@ func Default() Config {
 		},
 		JWT: jwt.Config{
 			AccessTokenSecret: "secret",
+			RefreshTokenTTL:   refreshTokenTTL,
+		},
+		Security: security.Config{
+			Password: security.Password{
+				Algorithm:  security.Argon2ID,
+				BcryptCost: 12,
+				Argon2: security.Argon2{
+					Time:       2,
+					Memory:     19 * 1024,
+					Threads:    1,
+					KeyLength:  32,
+					SaltLength: 16,
+				},
+			},
+		},
+		Home: home.Config{
+			CursorSecret: "secret",
+			Uploads:      4,
+		},
+		Imaging: imaging.Config{
+			MaxWidth:  4096,
+			MaxHeight: 4096,
+			Thumbnails: map[string]int{
+				"small":  320,
+				"medium": 800,
+			},
+		},
+		HTTP: server.Config{
+			Address:           ":1378",
+			ReadTimeout:       requestTimeout,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      requestTimeout,
+			IdleTimeout:       requestTimeout,
+			MaxHeaderBytes:    1 << 20,
+			MaxBodyBytes:      maxBodyBytes,
+			TLS: server.TLSConfig{
+				CertFile: "",
+				KeyFile:  "",
+			},
 		},
 	}
 }
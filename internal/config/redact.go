@@ -0,0 +1,32 @@
+package config
+
+import "net/url"
+
+// redacted replaces the secrets when the configuration is printed.
+const redacted = "[REDACTED]"
+
+// redact hides the given secret, the empty secrets are kept so the missing ones are visible.
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+
+	return redacted
+}
+
+// Redacted returns a copy of the configuration without its secrets, so it can be printed or logged.
+// The password of the database URL is redacted too.
+func (c Config) Redacted() Config {
+	c.JWT.AccessTokenSecret = redact(c.JWT.AccessTokenSecret)
+	c.FileStorage.SecretKey = redact(c.FileStorage.SecretKey)
+	c.FileStorage.Local.Secret = redact(c.FileStorage.Local.Secret)
+	c.Home.CursorSecret = redact(c.Home.CursorSecret)
+
+	if u, err := url.Parse(c.Database.URL); err == nil {
+		c.Database.URL = u.Redacted()
+	} else {
+		c.Database.URL = redact(c.Database.URL)
+	}
+
+	return c
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/knadh/koanf/v2"
+)
+
+// FileSuffix is added to the key of a secret to read the secret from a file, e.g. jwt.access_secret_file,
+// so the docker and kubernetes secrets can be mounted as files.
+const FileSuffix = "_file"
+
+var ErrUndefinedVariable = errors.New("environment variable is not defined")
+
+// secrets are the keys which can be read from files.
+// nolint: gochecknoglobals
+var secrets = []string{
+	"database.url",
+	"file_storage.access_key",
+	"file_storage.secret_key",
+	"file_storage.local.secret",
+	"jwt.access_secret",
+	"home.cursor_secret",
+}
+
+// reference is an ${ENV} reference to an environment variable.
+var reference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// files returns the keys which give the files of the secrets.
+func files() []string {
+	keys := make([]string, 0, len(secrets))
+
+	for _, secret := range secrets {
+		keys = append(keys, secret+FileSuffix)
+	}
+
+	return keys
+}
+
+// interpolate replaces the ${ENV} references of the string values with their environment variables.
+// The errors only have the names of the keys and the variables, not their values.
+func interpolate(k *koanf.Koanf) error {
+	var errs []error
+
+	for key, value := range k.All() {
+		s, ok := value.(string)
+		if !ok || !reference.MatchString(s) {
+			continue
+		}
+
+		s = reference.ReplaceAllStringFunc(s, func(ref string) string {
+			name := reference.FindStringSubmatch(ref)[1]
+
+			v, ok := os.LookupEnv(name)
+			if !ok {
+				errs = append(errs, fmt.Errorf("%s: %w: %s", key, ErrUndefinedVariable, name))
+			}
+
+			return v
+		})
+
+		if err := k.Set(key, s); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// resolve reads the secrets which are given by their files, the trailing newline of the files is removed.
+// A secret file overrides the secret value.
+func resolve(k *koanf.Koanf) error {
+	for _, secret := range secrets {
+		path := k.String(secret + FileSuffix)
+		if path == "" {
+			continue
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("error reading %s%s: %w", secret, FileSuffix, err)
+		}
+
+		if err := k.Set(secret, strings.TrimRight(string(content), "\r\n")); err != nil {
+			return fmt.Errorf("%s: %w", secret, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/1995parham-teaching/fandogh/internal/db"
+	"github.com/1995parham-teaching/fandogh/internal/fs"
+	"github.com/1995parham-teaching/fandogh/internal/http/server"
+	"github.com/1995parham-teaching/fandogh/internal/security"
+)
+
+var (
+	ErrRequired           = errors.New("is required")
+	ErrDefaultSecret      = errors.New("must not be the default secret in production")
+	ErrUnknownEnvironment = errors.New("unknown environment")
+)
+
+// Validate checks the required fields of the configuration and rejects the default secrets in production.
+// It reports all the invalid fields together.
+func (c Config) Validate() error {
+	var errs []error
+
+	required := func(key string, value string) {
+		if value == "" {
+			errs = append(errs, fmt.Errorf("%s %w", key, ErrRequired))
+		}
+	}
+
+	// secret is required and in production it must be changed from its default.
+	defaults := Default()
+	secret := func(key string, value string, def string) {
+		required(key, value)
+
+		if c.Environment == Production && value == def {
+			errs = append(errs, fmt.Errorf("%s %w", key, ErrDefaultSecret))
+		}
+	}
+
+	switch c.Environment {
+	case Development, Production:
+	default:
+		errs = append(errs, fmt.Errorf("environment %w: %q", ErrUnknownEnvironment, c.Environment))
+	}
+
+	switch c.Database.Driver {
+	case db.DriverMongo:
+		required("database.url", c.Database.URL)
+		required("database.name", c.Database.Name)
+	case db.DriverMemory:
+	default:
+		errs = append(errs, fmt.Errorf("database.driver %w: %q", db.ErrUnknownDriver, c.Database.Driver))
+	}
+
+	switch c.FileStorage.Driver {
+	case fs.DriverS3:
+		required("file_storage.endpoint", c.FileStorage.Endpoint)
+		required("file_storage.access_key", c.FileStorage.AccessKey)
+		required("file_storage.secret_key", c.FileStorage.SecretKey)
+	case fs.DriverLocal:
+		required("file_storage.local.root", c.FileStorage.Local.Root)
+		required("file_storage.local.url", c.FileStorage.Local.URL)
+		secret("file_storage.local.secret", c.FileStorage.Local.Secret, defaults.FileStorage.Local.Secret)
+	default:
+		errs = append(errs, fmt.Errorf("file_storage.driver %w: %q", fs.ErrUnknownDriver, c.FileStorage.Driver))
+	}
+
+	// the access secret is not used when the tokens are signed by a key.
+	if c.JWT.SigningKey == "" {
+		secret("jwt.access_secret", c.JWT.AccessTokenSecret, defaults.JWT.AccessTokenSecret)
+	}
+
+	secret("home.cursor_secret", c.Home.CursorSecret, defaults.Home.CursorSecret)
+
+	switch c.Security.Password.Algorithm {
+	case security.Argon2ID, security.Bcrypt:
+	default:
+		errs = append(errs, fmt.Errorf(
+			"security.password.algorithm %w: %q", security.ErrUnknownAlgorithm, c.Security.Password.Algorithm,
+		))
+	}
+
+	required("http.address", c.HTTP.Address)
+
+	if (c.HTTP.TLS.CertFile == "") != (c.HTTP.TLS.KeyFile == "") {
+		errs = append(errs, fmt.Errorf("http.tls: %w", server.ErrIncompleteTLS))
+	}
+
+	return errors.Join(errs...)
+}
@@ -1,7 +1,24 @@
 package db
 
-// Config contains the database configuration.
+import "errors"
+
+// Database drivers.
+const (
+	DriverMongo  = "mongo"
+	DriverMemory = "memory"
+)
+
+var (
+	// ErrUnknownDriver indicates a database driver which is not supported.
+	ErrUnknownDriver = errors.New("unknown database driver")
+	// ErrNoDatabase indicates the memory driver, which keeps the stores in the server memory without a database.
+	ErrNoDatabase = errors.New("memory driver has no database")
+)
+
+// Config contains the database configuration, Driver selects MongoDB (mongo) or the memory stores (memory),
+// which lose their data when the server stops, so they are only for the local runs.
 type Config struct {
-	Name string `koanf:"name"`
-	URL  string `koanf:"url"`
+	Driver string `koanf:"driver"`
+	Name   string `koanf:"name"`
+	URL    string `koanf:"url"`
 }
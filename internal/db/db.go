@@ -17,6 +17,10 @@ const connectionTimeout = 10 * time.Second
 
 // Provide creates a new mongodb connection with lifecycle management.
 func Provide(lc fx.Lifecycle, cfg Config, logger *zap.Logger) (*mongo.Database, error) {
+	if cfg.Driver != DriverMongo {
+		return nil, fmt.Errorf("%w: %s", ErrNoDatabase, cfg.Driver)
+	}
+
 	opts := options.Client()
 	opts.ApplyURI(cfg.URL)
 	opts.SetMonitor(otelmongo.NewMonitor())
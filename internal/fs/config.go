@@ -1,9 +1,30 @@
 package fs
 
+import "time"
+
+// Storage drivers.
+const (
+	DriverS3    = "s3"
+	DriverLocal = "local"
+)
+
+// Config of the storage, Driver selects the S3-compatible storage (s3) or a local directory (local).
+// PresignExpiry is the lifetime of the presigned URLs.
 type Config struct {
-	Endpoint  string `koanf:"endpoint"`
-	AccessKey string `koanf:"access_key"`
-	SecretKey string `koanf:"secret_key"`
-	UseSSL    bool   `koanf:"use_ssl"`
-	Region    string `koanf:"region"`
+	Driver        string        `koanf:"driver"`
+	Endpoint      string        `koanf:"endpoint"`
+	AccessKey     string        `koanf:"access_key"`
+	SecretKey     string        `koanf:"secret_key"`
+	UseSSL        bool          `koanf:"use_ssl"`
+	Region        string        `koanf:"region"`
+	PresignExpiry time.Duration `koanf:"presign_expiry"`
+	Local         LocalConfig   `koanf:"local"`
+}
+
+// LocalConfig of the local directory storage. URL is the address which the server serves the presigned URLs on
+// and Secret signs them.
+type LocalConfig struct {
+	Root   string `koanf:"root"`
+	URL    string `koanf:"url"`
+	Secret string `koanf:"secret"`
 }
@@ -0,0 +1,447 @@
+package fs
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Directories of each bucket in the local storage.
+const (
+	objectsDir    = "objects"
+	attributesDir = "attributes"
+)
+
+const defaultContentType = "application/octet-stream"
+
+// temporary is the name prefix of the files which are being written.
+const temporary = ".upload-"
+
+// Local stores the objects in a local directory for development and tests. Each bucket is a directory which has
+// the objects as files and their attributes as JSON files with the same path. Keys are paths, so a key cannot be
+// the prefix directory of another key.
+// Local serves its presigned URLs as an http.Handler, the URLs are signed with HMAC-SHA256.
+type Local struct {
+	root   string
+	url    string
+	secret []byte
+	expiry time.Duration
+}
+
+// attributes are stored next to each object.
+type attributes struct {
+	ContentType string            `json:"content_type"`
+	Metadata    map[string]string `json:"metadata"`
+}
+
+// NewLocal creates a storage in the configured directory.
+func NewLocal(cfg Config) (*Local, error) {
+	if cfg.Local.Root == "" {
+		return nil, errors.New("local storage root is required")
+	}
+
+	if cfg.Local.Secret == "" {
+		return nil, errors.New("local storage secret is required for signing the presigned urls")
+	}
+
+	return &Local{
+		root:   cfg.Local.Root,
+		url:    strings.TrimSuffix(cfg.Local.URL, "/"),
+		secret: []byte(cfg.Local.Secret),
+		expiry: cfg.PresignExpiry,
+	}, nil
+}
+
+// path returns the path of the given object or its attributes, keys must be local paths so they cannot escape
+// their bucket.
+func (l *Local) path(bucket string, dir string, key string) (string, error) {
+	if bucket == "" || !filepath.IsLocal(bucket) || strings.ContainsRune(bucket, '/') || !filepath.IsLocal(key) {
+		return "", fmt.Errorf("%w: %s/%s", ErrInvalidKey, bucket, key)
+	}
+
+	return filepath.Join(l.root, bucket, dir, filepath.FromSlash(key)), nil
+}
+
+// Bucket ensures the directory of the specified bucket exists.
+func (l *Local) Bucket(_ context.Context, bucket string) error {
+	path, err := l.path(bucket, "", ".")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(path, 0o750); err != nil {
+		return fmt.Errorf("cannot create bucket [%s]: %w", bucket, err)
+	}
+
+	return nil
+}
+
+func (l *Local) Ping(_ context.Context, bucket string) error {
+	path, err := l.path(bucket, "", ".")
+	if err != nil {
+		return err
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("cannot stat bucket [%s]: %w", bucket, err)
+	}
+
+	if !stat.IsDir() {
+		return fmt.Errorf("bucket [%s] is not a directory: %w", bucket, ErrNotFound)
+	}
+
+	return nil
+}
+
+func (l *Local) Put(_ context.Context, bucket string, key string, object Object) error {
+	path, err := l.path(bucket, objectsDir, key)
+	if err != nil {
+		return err
+	}
+
+	if err := write(path, object.Content); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(attributes{
+		ContentType: object.ContentType,
+		Metadata:    object.Metadata,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot encode object attributes: %w", err)
+	}
+
+	path, err = l.path(bucket, attributesDir, key+".json")
+	if err != nil {
+		return err
+	}
+
+	return write(path, strings.NewReader(string(data)))
+}
+
+// write replaces the given file atomically, so readers never see a partial file.
+func write(path string, content io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("cannot create object directory: %w", err)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), temporary+"*")
+	if err != nil {
+		return fmt.Errorf("cannot create object: %w", err)
+	}
+
+	if _, err := io.Copy(f, content); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+
+		return fmt.Errorf("cannot write object: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+
+		return fmt.Errorf("cannot write object: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		_ = os.Remove(f.Name())
+
+		return fmt.Errorf("cannot write object: %w", err)
+	}
+
+	return nil
+}
+
+func (l *Local) Get(ctx context.Context, bucket string, key string) (io.ReadCloser, Info, error) {
+	info, err := l.Stat(ctx, bucket, key)
+	if err != nil {
+		return nil, info, err
+	}
+
+	path, err := l.path(bucket, objectsDir, key)
+	if err != nil {
+		return nil, info, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, info, ErrNotFound
+		}
+
+		return nil, info, fmt.Errorf("cannot open object [%s/%s]: %w", bucket, key, err)
+	}
+
+	return f, info, nil
+}
+
+func (l *Local) Stat(_ context.Context, bucket string, key string) (Info, error) {
+	path, err := l.path(bucket, objectsDir, key)
+	if err != nil {
+		return Info{}, err
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil || stat.IsDir() {
+		if err == nil || errors.Is(err, os.ErrNotExist) {
+			return Info{}, ErrNotFound
+		}
+
+		return Info{}, fmt.Errorf("cannot stat object [%s/%s]: %w", bucket, key, err)
+	}
+
+	info := Info{
+		Size:         stat.Size(),
+		ContentType:  defaultContentType,
+		Metadata:     nil,
+		LastModified: stat.ModTime(),
+	}
+
+	path, err = l.path(bucket, attributesDir, key+".json")
+	if err != nil {
+		return Info{}, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		// objects which are copied into the directory by hand do not have attributes.
+		if errors.Is(err, os.ErrNotExist) {
+			return info, nil
+		}
+
+		return Info{}, fmt.Errorf("cannot read object attributes [%s/%s]: %w", bucket, key, err)
+	}
+
+	var attrs attributes
+
+	if err := json.Unmarshal(data, &attrs); err != nil {
+		return Info{}, fmt.Errorf("cannot decode object attributes [%s/%s]: %w", bucket, key, err)
+	}
+
+	if attrs.ContentType != "" {
+		info.ContentType = attrs.ContentType
+	}
+
+	info.Metadata = attrs.Metadata
+
+	return info, nil
+}
+
+func (l *Local) Copy(
+	ctx context.Context, bucket string, from string, to string, contentType string, metadata map[string]string,
+) error {
+	content, info, err := l.Get(ctx, bucket, from)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	return l.Put(ctx, bucket, to, Object{
+		Content:     content,
+		Size:        info.Size,
+		ContentType: contentType,
+		Metadata:    metadata,
+	})
+}
+
+func (l *Local) Delete(_ context.Context, bucket string, key string) error {
+	for _, p := range []struct{ dir, name string }{{objectsDir, key}, {attributesDir, key + ".json"}} {
+		path, err := l.path(bucket, p.dir, p.name)
+		if err != nil {
+			return err
+		}
+
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("cannot delete object [%s/%s]: %w", bucket, key, err)
+		}
+	}
+
+	return nil
+}
+
+func (l *Local) List(_ context.Context, bucket string, prefix string) iter.Seq2[Entry, error] {
+	return func(yield func(Entry, error) bool) {
+		root, err := l.path(bucket, objectsDir, ".")
+		if err != nil {
+			yield(Entry{}, err)
+
+			return
+		}
+
+		err = filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				// buckets have no directory until their first object.
+				if path == root && errors.Is(err, os.ErrNotExist) {
+					return filepath.SkipAll
+				}
+
+				return err
+			}
+
+			if d.IsDir() || strings.HasPrefix(d.Name(), temporary) {
+				return nil
+			}
+
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+
+			key := filepath.ToSlash(rel)
+			if !strings.HasPrefix(key, prefix) {
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				// the object is removed during the listing.
+				if errors.Is(err, os.ErrNotExist) {
+					return nil
+				}
+
+				return err
+			}
+
+			if !yield(Entry{Key: key, Size: info.Size(), LastModified: info.ModTime()}, nil) {
+				return filepath.SkipAll
+			}
+
+			return nil
+		})
+		if err != nil {
+			yield(Entry{}, fmt.Errorf("cannot list objects [%s/%s]: %w", bucket, prefix, err))
+		}
+	}
+}
+
+func (l *Local) PresignGet(_ context.Context, bucket string, key string) (string, error) {
+	return l.presign(http.MethodGet, bucket, key, "")
+}
+
+func (l *Local) PresignPut(_ context.Context, bucket string, key string, contentType string) (string, error) {
+	return l.presign(http.MethodPut, bucket, key, contentType)
+}
+
+func (l *Local) PresignExpiry() time.Duration {
+	return l.expiry
+}
+
+// presign creates a URL for the given request on the object, which is valid until the expiry.
+func (l *Local) presign(method string, bucket string, key string, contentType string) (string, error) {
+	if _, err := l.path(bucket, objectsDir, key); err != nil {
+		return "", err
+	}
+
+	expires := strconv.FormatInt(time.Now().Add(l.expiry).Unix(), 10)
+
+	// nolint: exhaustruct
+	path := url.URL{Path: "/" + bucket + "/" + key}
+
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("signature", l.sign(method, bucket, key, expires, contentType))
+
+	return l.url + path.EscapedPath() + "?" + query.Encode(), nil
+}
+
+// sign returns the signature of the given request on the object.
+func (l *Local) sign(method string, bucket string, key string, expires string, contentType string) string {
+	mac := hmac.New(sha256.New, l.secret)
+	mac.Write([]byte(strings.Join([]string{method, bucket, key, expires, contentType}, "\n")))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ServeHTTP downloads and uploads the objects with the presigned URLs, the URL path must be /<bucket>/<key>.
+// Uploads must have the content type which is given on presigning.
+func (l *Local) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, key, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if !ok {
+		http.NotFound(w, r)
+
+		return
+	}
+
+	method := r.Method
+	if method == http.MethodHead {
+		method = http.MethodGet
+	}
+
+	contentType := ""
+	if method == http.MethodPut {
+		contentType = r.Header.Get("Content-Type")
+	}
+
+	expires := r.URL.Query().Get("expires")
+
+	deadline, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > deadline || !hmac.Equal(
+		[]byte(r.URL.Query().Get("signature")),
+		[]byte(l.sign(method, bucket, key, expires, contentType)),
+	) {
+		http.Error(w, "invalid or expired signature", http.StatusForbidden)
+
+		return
+	}
+
+	switch method {
+	case http.MethodGet:
+		l.download(w, r, bucket, key)
+	case http.MethodPut:
+		err := l.Put(r.Context(), bucket, key, Object{
+			Content:     r.Body,
+			Size:        r.ContentLength,
+			ContentType: contentType,
+			Metadata:    nil,
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (l *Local) download(w http.ResponseWriter, r *http.Request, bucket string, key string) {
+	content, info, err := l.Get(r.Context(), bucket, key)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", info.ContentType)
+
+	if f, ok := content.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, "", info.LastModified, f)
+
+		return
+	}
+
+	_, _ = io.Copy(w, content)
+}
@@ -0,0 +1,175 @@
+package fs_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1995parham-teaching/fandogh/internal/fs"
+	"github.com/stretchr/testify/require"
+)
+
+func local(t *testing.T) *fs.Local {
+	t.Helper()
+
+	storage, err := fs.NewLocal(fs.Config{
+		Driver:        fs.DriverLocal,
+		Endpoint:      "",
+		AccessKey:     "",
+		SecretKey:     "",
+		UseSSL:        false,
+		Region:        "",
+		PresignExpiry: time.Minute,
+		Local: fs.LocalConfig{
+			Root:   t.TempDir(),
+			URL:    "",
+			Secret: "secret",
+		},
+	})
+	require.NoError(t, err)
+
+	return storage
+}
+
+func TestLocal(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	storage := local(t)
+
+	require.Error(t, storage.Ping(ctx, "photos"))
+	require.NoError(t, storage.Bucket(ctx, "photos"))
+	require.NoError(t, storage.Ping(ctx, "photos"))
+
+	_, err := storage.Stat(ctx, "photos", "homes/1/photos/a.png")
+	require.ErrorIs(t, err, fs.ErrNotFound)
+
+	require.NoError(t, storage.Put(ctx, "photos", "homes/1/photos/a.png", fs.Object{
+		Content:     strings.NewReader("123"),
+		Size:        3,
+		ContentType: "image/png",
+		Metadata:    fs.Metadata("kitchen 1.png"),
+	}))
+
+	info, err := storage.Stat(ctx, "photos", "homes/1/photos/a.png")
+	require.NoError(t, err)
+	require.Equal(t, int64(3), info.Size)
+	require.Equal(t, "image/png", info.ContentType)
+	require.Equal(t, fs.Metadata("kitchen 1.png"), info.Metadata)
+
+	require.NoError(t, storage.Copy(ctx, "photos", "homes/1/photos/a.png", "homes/1/photos/b.jpg", "image/jpeg", nil))
+
+	content, info, err := storage.Get(ctx, "photos", "homes/1/photos/b.jpg")
+	require.NoError(t, err)
+	require.Equal(t, "image/jpeg", info.ContentType)
+
+	data, err := io.ReadAll(content)
+	require.NoError(t, err)
+	require.NoError(t, content.Close())
+	require.Equal(t, "123", string(data))
+
+	require.NoError(t, storage.Delete(ctx, "photos", "homes/1/photos/a.png"))
+	require.NoError(t, storage.Delete(ctx, "photos", "homes/1/photos/a.png"))
+
+	_, _, err = storage.Get(ctx, "photos", "homes/1/photos/a.png")
+	require.ErrorIs(t, err, fs.ErrNotFound)
+
+	for _, key := range []string{"../a.png", "homes/../../a.png", "/etc/passwd", ""} {
+		require.ErrorIs(t, storage.Put(ctx, "photos", key, fs.Object{
+			Content:     strings.NewReader("123"),
+			Size:        3,
+			ContentType: "image/png",
+			Metadata:    nil,
+		}), fs.ErrInvalidKey, key)
+	}
+}
+
+func TestLocalList(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	storage := local(t)
+
+	list := func(prefix string) []string {
+		keys := make([]string, 0)
+
+		for entry, err := range storage.List(ctx, "photos", prefix) {
+			require.NoError(t, err)
+
+			keys = append(keys, entry.Key)
+		}
+
+		return keys
+	}
+
+	require.Empty(t, list(""))
+
+	for _, key := range []string{"homes/1/photos/a.png", "homes/1/thumbnails/small/a.png", "homes/2/photos/b.png"} {
+		require.NoError(t, storage.Put(ctx, "photos", key, fs.Object{
+			Content:     strings.NewReader("123"),
+			Size:        3,
+			ContentType: "image/png",
+			Metadata:    nil,
+		}))
+	}
+
+	require.ElementsMatch(t, []string{
+		"homes/1/photos/a.png", "homes/1/thumbnails/small/a.png", "homes/2/photos/b.png",
+	}, list(""))
+	require.ElementsMatch(t, []string{"homes/1/photos/a.png", "homes/1/thumbnails/small/a.png"}, list("homes/1/"))
+
+	for entry, err := range storage.List(ctx, "photos", "homes/2/") {
+		require.NoError(t, err)
+		require.Equal(t, int64(3), entry.Size)
+		require.WithinDuration(t, time.Now(), entry.LastModified, time.Minute)
+	}
+}
+
+func TestLocalPresign(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	storage := local(t)
+
+	server := httptest.NewServer(storage)
+	defer server.Close()
+
+	put, err := storage.PresignPut(ctx, "photos", "homes/a%2Fb/uploads/a.png", "image/png")
+	require.NoError(t, err)
+
+	upload := func(url string, contentType string) int {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, server.URL+url, strings.NewReader("123"))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", contentType)
+
+		rsp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, rsp.Body.Close())
+
+		return rsp.StatusCode
+	}
+
+	require.Equal(t, http.StatusForbidden, upload(put, "image/jpeg"))
+	require.Equal(t, http.StatusForbidden, upload(strings.Replace(put, "uploads", "photos", 1), "image/png"))
+	require.Equal(t, http.StatusOK, upload(put, "image/png"))
+
+	get, err := storage.PresignGet(ctx, "photos", "homes/a%2Fb/uploads/a.png")
+	require.NoError(t, err)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+get, nil)
+	require.NoError(t, err)
+
+	rsp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+
+	data, err := io.ReadAll(rsp.Body)
+	require.NoError(t, err)
+	require.NoError(t, rsp.Body.Close())
+	require.Equal(t, http.StatusOK, rsp.StatusCode)
+	require.Equal(t, "image/png", rsp.Header.Get("Content-Type"))
+	require.Equal(t, "123", string(data))
+}
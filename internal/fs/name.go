@@ -2,30 +2,162 @@ package fs
 
 import (
 	"errors"
-	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
 )
 
 var ErrInvalidName = errors.New("invalid name")
 
-const components = 2
+// Kind is the kind of the objects of a home, which is a component of their keys.
+type Kind string
+
+const (
+	// KindPhoto is a processed photo.
+	KindPhoto Kind = "photos"
+	// KindThumbnail is a thumbnail of a processed photo.
+	KindThumbnail Kind = "thumbnails"
+	// KindUpload is a photo which is uploaded with a presigned URL and is not processed yet.
+	KindUpload Kind = "uploads"
+)
+
+// Components of the keys.
+const (
+	homes      = "homes"
+	components = 4
+)
+
+// metadataName is the object metadata which keeps the photo name.
+const metadataName = "name"
+
+// Key is the key of an object of a home with the following layout, the home id and the size are escaped,
+// so they can contain any character. The photo names are not part of the keys, they are kept in the object metadata.
+//
+//	homes/<home>/photos/<id>.<ext>
+//	homes/<home>/thumbnails/<size>/<id>.<ext>
+//	homes/<home>/uploads/<id>.<ext>
+type Key struct {
+	Home string
+	Kind Kind
+	Size string
+	ID   string
+	Ext  string
+}
+
+// Generate generates a new photo key for the given home with an extension based on the given content type.
+func Generate(homeID string, contentType string) Key {
+	return Key{
+		Home: homeID,
+		Kind: KindPhoto,
+		Size: "",
+		ID:   uuid.New().String(),
+		Ext:  Extension(contentType),
+	}
+}
+
+// GenerateUpload generates a new upload key for the given home with an extension based on the given content type.
+func GenerateUpload(homeID string, contentType string) Key {
+	key := Generate(homeID, contentType)
+	key.Kind = KindUpload
+
+	return key
+}
+
+// Thumbnail returns the key of the thumbnail of given size of the photo.
+func (k Key) Thumbnail(size string) Key {
+	k.Kind = KindThumbnail
+	k.Size = size
+
+	return k
+}
+
+// Prefix returns the prefix of the keys of the given home objects.
+func Prefix(homeID string) string {
+	return homes + "/" + url.PathEscape(homeID) + "/"
+}
+
+func (k Key) String() string {
+	name := k.ID + "." + k.Ext
 
-// Generate filename for given photo of the given home.
-func Generate(homeID string, name string) string {
-	return fmt.Sprintf("%s_%s", homeID, name)
+	if k.Kind == KindThumbnail {
+		return Prefix(k.Home) + string(k.Kind) + "/" + url.PathEscape(k.Size) + "/" + name
+	}
+
+	return Prefix(k.Home) + string(k.Kind) + "/" + name
 }
 
-// Parse given filename to its home and photo name.
-func Parse(name string) (string, string, error) {
-	var id, photo string
+// Parse given key to its components, it is the reverse of Key.String.
+func Parse(key string) (Key, error) {
+	parts := strings.Split(key, "/")
+	if len(parts) < components || parts[0] != homes {
+		return Key{}, ErrInvalidName
+	}
+
+	home, err := url.PathUnescape(parts[1])
+	if err != nil || home == "" {
+		return Key{}, ErrInvalidName
+	}
+
+	result := Key{
+		Home: home,
+		Kind: Kind(parts[2]),
+		Size: "",
+		ID:   "",
+		Ext:  "",
+	}
+
+	switch result.Kind {
+	case KindPhoto, KindUpload:
+		if len(parts) != components {
+			return Key{}, ErrInvalidName
+		}
+	case KindThumbnail:
+		if len(parts) != components+1 {
+			return Key{}, ErrInvalidName
+		}
+
+		result.Size, err = url.PathUnescape(parts[3])
+		if err != nil || result.Size == "" {
+			return Key{}, ErrInvalidName
+		}
+	default:
+		return Key{}, ErrInvalidName
+	}
 
-	n, err := fmt.Sscanf(name, "%s_%s", &id, &photo)
-	if err != nil {
-		return "", "", ErrInvalidName
+	id, ext, ok := strings.Cut(parts[len(parts)-1], ".")
+	if !ok || ext == "" {
+		return Key{}, ErrInvalidName
 	}
 
-	if n != components {
-		return "", "", ErrInvalidName
+	if parsed, err := uuid.Parse(id); err != nil || parsed.String() != id {
+		return Key{}, ErrInvalidName
 	}
 
-	return id, photo, nil
+	result.ID = id
+	result.Ext = ext
+
+	return result, nil
+}
+
+// Metadata returns the object metadata which keeps the given photo name. The name is escaped,
+// because the metadata are sent as HTTP headers.
+func Metadata(name string) map[string]string {
+	return map[string]string{metadataName: url.PathEscape(name)}
+}
+
+// Extension returns the file extension of the given photo content type.
+func Extension(contentType string) string {
+	switch contentType {
+	case "image/jpeg":
+		return "jpg"
+	case "image/png":
+		return "png"
+	case "image/gif":
+		return "gif"
+	case "image/webp":
+		return "webp"
+	default:
+		return "bin"
+	}
 }
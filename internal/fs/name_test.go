@@ -0,0 +1,52 @@
+package fs_test
+
+import (
+	"testing"
+
+	"github.com/1995parham-teaching/fandogh/internal/fs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKey(t *testing.T) {
+	t.Parallel()
+
+	photo := fs.Generate("6523f2b0e1a4c0d9a8b7c6d5", "image/jpeg")
+	require.Equal(t, "homes/6523f2b0e1a4c0d9a8b7c6d5/photos/"+photo.ID+".jpg", photo.String())
+
+	for _, key := range []fs.Key{
+		photo,
+		photo.Thumbnail("small"),
+		photo.Thumbnail("x/y_z"),
+		fs.GenerateUpload("home/with_separators", "image/webp"),
+	} {
+		parsed, err := fs.Parse(key.String())
+		require.NoError(t, err)
+		require.Equal(t, key, parsed)
+	}
+
+	require.Equal(t, "homes/a%2Fb/thumbnails/x%2Fy/"+photo.ID+".jpg", fs.Key{
+		Home: "a/b",
+		Kind: fs.KindThumbnail,
+		Size: "x/y",
+		ID:   photo.ID,
+		Ext:  "jpg",
+	}.String())
+}
+
+func TestParseInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, key := range []string{
+		"",
+		"6523f2b0e1a4c0d9a8b7c6d5_kitchen.png",
+		"thumbnails/small/6523f2b0e1a4c0d9a8b7c6d5_kitchen.png",
+		"homes/6523f2b0e1a4c0d9a8b7c6d5/photos/kitchen.png",
+		"homes/6523f2b0e1a4c0d9a8b7c6d5/photos/2c4b2f1e-6b8a-4c1d-9e0f-3a2b1c0d9e8f",
+		"homes/6523f2b0e1a4c0d9a8b7c6d5/rooms/2c4b2f1e-6b8a-4c1d-9e0f-3a2b1c0d9e8f.png",
+		"homes/6523f2b0e1a4c0d9a8b7c6d5/thumbnails/2c4b2f1e-6b8a-4c1d-9e0f-3a2b1c0d9e8f.png",
+		"homes//photos/2c4b2f1e-6b8a-4c1d-9e0f-3a2b1c0d9e8f.png",
+	} {
+		_, err := fs.Parse(key)
+		require.ErrorIs(t, err, fs.ErrInvalidName, key)
+	}
+}
@@ -0,0 +1,322 @@
+package fs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/url"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// S3 stores the objects in an S3-compatible storage (e.g., RustFS, MinIO).
+type S3 struct {
+	client  *s3.Client
+	presign *s3.PresignClient
+	expiry  time.Duration
+}
+
+// NewS3 creates a connection to an S3-compatible storage.
+func NewS3(cfg Config) *S3 {
+	scheme := "http"
+	if cfg.UseSSL {
+		scheme = "https"
+	}
+
+	// nolint: exhaustruct
+	client := s3.New(s3.Options{
+		BaseEndpoint: aws.String(fmt.Sprintf("%s://%s", scheme, cfg.Endpoint)),
+		Region:       cfg.Region,
+		Credentials:  credentials.NewStaticCredentialsProvider(cfg.AccessKey, cfg.SecretKey, ""),
+		UsePathStyle: true,
+	})
+
+	return &S3{
+		client:  client,
+		presign: s3.NewPresignClient(client),
+		expiry:  cfg.PresignExpiry,
+	}
+}
+
+// Bucket ensures the specified bucket exists in the S3-compatible storage.
+func (s *S3) Bucket(ctx context.Context, bucket string) error {
+	// nolint: exhaustruct
+	_, err := s.client.HeadBucket(ctx, &s3.HeadBucketInput{
+		Bucket: aws.String(bucket),
+	})
+	if err == nil {
+		return nil
+	}
+
+	// nolint: exhaustruct
+	_, err = s.client.CreateBucket(ctx, &s3.CreateBucketInput{
+		Bucket: aws.String(bucket),
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create bucket [%s]: %w", bucket, err)
+	}
+
+	return nil
+}
+
+func (s *S3) Ping(ctx context.Context, bucket string) error {
+	// nolint: exhaustruct
+	_, err := s.client.HeadBucket(ctx, &s3.HeadBucketInput{
+		Bucket: aws.String(bucket),
+	})
+	if err != nil {
+		return fmt.Errorf("s3 bucket head failed [%s]: %w", bucket, err)
+	}
+
+	return nil
+}
+
+func (s *S3) Put(ctx context.Context, bucket string, key string, object Object) error {
+	if object.Size == UnknownSize {
+		return s.stream(ctx, bucket, key, object)
+	}
+
+	// nolint: exhaustruct
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(bucket),
+		Key:           aws.String(key),
+		Body:          object.Content,
+		ContentLength: aws.Int64(object.Size),
+		ContentType:   aws.String(object.ContentType),
+		Metadata:      object.Metadata,
+	})
+	if err != nil {
+		return fmt.Errorf("s3 object creation failed [%s/%s]: %w", bucket, key, err)
+	}
+
+	return nil
+}
+
+// partSize is the size of the parts of the streamed objects, S3 requires at least 5 MiB for each part but the last.
+const partSize = 5 << 20
+
+// stream puts an object without knowing its size with a multipart upload, so only one of its parts is in memory.
+// The objects which fit in one part are put directly.
+// nolint: funlen
+func (s *S3) stream(ctx context.Context, bucket string, key string, object Object) error {
+	part := make([]byte, partSize)
+
+	n, err := io.ReadFull(object.Content, part)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		object.Content = bytes.NewReader(part[:n])
+		object.Size = int64(n)
+
+		return s.Put(ctx, bucket, key, object)
+	}
+
+	if err != nil {
+		return fmt.Errorf("s3 object read failed [%s/%s]: %w", bucket, key, err)
+	}
+
+	// nolint: exhaustruct
+	upload, err := s.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(object.ContentType),
+		Metadata:    object.Metadata,
+	})
+	if err != nil {
+		return fmt.Errorf("s3 multipart upload creation failed [%s/%s]: %w", bucket, key, err)
+	}
+
+	// the uploaded parts are removed when the upload fails.
+	abort := func(err error) error {
+		// nolint: exhaustruct
+		_, _ = s.client.AbortMultipartUpload(context.WithoutCancel(ctx), &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(bucket),
+			Key:      aws.String(key),
+			UploadId: upload.UploadId,
+		})
+
+		return err
+	}
+
+	parts := make([]types.CompletedPart, 0)
+
+	for number := int32(1); n > 0; number++ {
+		// nolint: exhaustruct
+		uploaded, err := s.client.UploadPart(ctx, &s3.UploadPartInput{
+			Bucket:        aws.String(bucket),
+			Key:           aws.String(key),
+			UploadId:      upload.UploadId,
+			PartNumber:    aws.Int32(number),
+			Body:          bytes.NewReader(part[:n]),
+			ContentLength: aws.Int64(int64(n)),
+		})
+		if err != nil {
+			return abort(fmt.Errorf("s3 part upload failed [%s/%s]: %w", bucket, key, err))
+		}
+
+		// nolint: exhaustruct
+		parts = append(parts, types.CompletedPart{ETag: uploaded.ETag, PartNumber: aws.Int32(number)})
+
+		n, err = io.ReadFull(object.Content, part)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return abort(fmt.Errorf("s3 object read failed [%s/%s]: %w", bucket, key, err))
+		}
+	}
+
+	// nolint: exhaustruct
+	_, err = s.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(bucket),
+		Key:             aws.String(key),
+		UploadId:        upload.UploadId,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		return abort(fmt.Errorf("s3 multipart upload completion failed [%s/%s]: %w", bucket, key, err))
+	}
+
+	return nil
+}
+
+func (s *S3) Get(ctx context.Context, bucket string, key string) (io.ReadCloser, Info, error) {
+	// nolint: exhaustruct
+	object, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		if _, ok := errors.AsType[*types.NoSuchKey](err); ok {
+			return nil, Info{}, ErrNotFound
+		}
+
+		return nil, Info{}, fmt.Errorf("s3 object get failed [%s/%s]: %w", bucket, key, err)
+	}
+
+	return object.Body, Info{
+		Size:         aws.ToInt64(object.ContentLength),
+		ContentType:  aws.ToString(object.ContentType),
+		Metadata:     object.Metadata,
+		LastModified: aws.ToTime(object.LastModified),
+	}, nil
+}
+
+func (s *S3) Stat(ctx context.Context, bucket string, key string) (Info, error) {
+	// nolint: exhaustruct
+	object, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		if _, ok := errors.AsType[*types.NotFound](err); ok {
+			return Info{}, ErrNotFound
+		}
+
+		return Info{}, fmt.Errorf("s3 object head failed [%s/%s]: %w", bucket, key, err)
+	}
+
+	return Info{
+		Size:         aws.ToInt64(object.ContentLength),
+		ContentType:  aws.ToString(object.ContentType),
+		Metadata:     object.Metadata,
+		LastModified: aws.ToTime(object.LastModified),
+	}, nil
+}
+
+func (s *S3) Copy(
+	ctx context.Context, bucket string, from string, to string, contentType string, metadata map[string]string,
+) error {
+	// nolint: exhaustruct
+	source := url.URL{Path: bucket + "/" + from}
+
+	// nolint: exhaustruct
+	_, err := s.client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:            aws.String(bucket),
+		Key:               aws.String(to),
+		CopySource:        aws.String(source.EscapedPath()),
+		ContentType:       aws.String(contentType),
+		Metadata:          metadata,
+		MetadataDirective: types.MetadataDirectiveReplace,
+	})
+	if err != nil {
+		return fmt.Errorf("s3 object copy failed [%s/%s]: %w", bucket, from, err)
+	}
+
+	return nil
+}
+
+func (s *S3) Delete(ctx context.Context, bucket string, key string) error {
+	// nolint: exhaustruct
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("s3 object deletion failed [%s/%s]: %w", bucket, key, err)
+	}
+
+	return nil
+}
+
+func (s *S3) List(ctx context.Context, bucket string, prefix string) iter.Seq2[Entry, error] {
+	return func(yield func(Entry, error) bool) {
+		// nolint: exhaustruct
+		pages := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+			Bucket: aws.String(bucket),
+			Prefix: aws.String(prefix),
+		})
+
+		for pages.HasMorePages() {
+			page, err := pages.NextPage(ctx)
+			if err != nil {
+				yield(Entry{}, fmt.Errorf("s3 object listing failed [%s/%s]: %w", bucket, prefix, err))
+
+				return
+			}
+
+			for _, object := range page.Contents {
+				if !yield(Entry{
+					Key:          aws.ToString(object.Key),
+					Size:         aws.ToInt64(object.Size),
+					LastModified: aws.ToTime(object.LastModified),
+				}, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *S3) PresignGet(ctx context.Context, bucket string, key string) (string, error) {
+	// nolint: exhaustruct
+	req, err := s.presign.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(s.expiry))
+	if err != nil {
+		return "", fmt.Errorf("cannot presign object download [%s/%s]: %w", bucket, key, err)
+	}
+
+	return req.URL, nil
+}
+
+func (s *S3) PresignPut(ctx context.Context, bucket string, key string, contentType string) (string, error) {
+	// nolint: exhaustruct
+	req, err := s.presign.PresignPutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+	}, s3.WithPresignExpires(s.expiry))
+	if err != nil {
+		return "", fmt.Errorf("cannot presign object upload [%s/%s]: %w", bucket, key, err)
+	}
+
+	return req.URL, nil
+}
+
+func (s *S3) PresignExpiry() time.Duration {
+	return s.expiry
+}
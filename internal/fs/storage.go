@@ -0,0 +1,80 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"time"
+)
+
+var (
+	ErrNotFound      = errors.New("object does not exist")
+	ErrInvalidKey    = errors.New("invalid object key")
+	ErrUnknownDriver = errors.New("unknown storage driver")
+)
+
+// UnknownSize is the size of the objects which are streamed without knowing their length, e.g. the files of
+// multipart forms.
+const UnknownSize = -1
+
+// Object is the content of an object with its attributes, Size must be the length of the content in bytes
+// or UnknownSize.
+type Object struct {
+	Content     io.Reader
+	Size        int64
+	ContentType string
+	Metadata    map[string]string
+}
+
+// Info contains the attributes of a stored object.
+type Info struct {
+	Size         int64
+	ContentType  string
+	Metadata     map[string]string
+	LastModified time.Time
+}
+
+// Entry is an object of a listing.
+type Entry struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// Storage stores the objects (e.g. photos) in buckets and creates presigned URLs for them,
+// so clients can download and upload the objects directly.
+type Storage interface {
+	// Bucket ensures the given bucket exists.
+	Bucket(ctx context.Context, bucket string) error
+	// Ping checks the given bucket exists and it is reachable without creating it.
+	Ping(ctx context.Context, bucket string) error
+	Put(ctx context.Context, bucket string, key string, object Object) error
+	// Get returns the content of the given object, which must be closed, with its attributes.
+	Get(ctx context.Context, bucket string, key string) (io.ReadCloser, Info, error)
+	Stat(ctx context.Context, bucket string, key string) (Info, error)
+	// Copy copies the given object to the given key with the given content type and metadata.
+	Copy(ctx context.Context, bucket string, from string, to string, contentType string, metadata map[string]string) error
+	// Delete removes the given object, removing an object which does not exist is not an error.
+	Delete(ctx context.Context, bucket string, key string) error
+	// List returns the objects which their keys start with the given prefix, the listing stops on the first error.
+	List(ctx context.Context, bucket string, prefix string) iter.Seq2[Entry, error]
+	PresignGet(ctx context.Context, bucket string, key string) (string, error)
+	// PresignPut creates a URL for uploading the given object with the given content type.
+	PresignPut(ctx context.Context, bucket string, key string, contentType string) (string, error)
+	// PresignExpiry is the lifetime of the presigned URLs.
+	PresignExpiry() time.Duration
+}
+
+// Provide creates the storage of the configured driver for dependency injection.
+func Provide(cfg Config) (Storage, error) {
+	switch cfg.Driver {
+	case DriverS3:
+		return NewS3(cfg), nil
+	case DriverLocal:
+		return NewLocal(cfg)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownDriver, cfg.Driver)
+	}
+}
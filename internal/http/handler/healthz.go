@@ -1,13 +1,23 @@
 package handler
 
 import (
+	"context"
 	"net/http"
+	"sync"
+	"time"
 
+	"github.com/1995parham-teaching/fandogh/internal/http/response"
 	"github.com/labstack/echo/v5"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
+// Dependency statuses in the readiness report.
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
 type Healthz struct {
 	Logger *zap.Logger
 	Tracer trace.Tracer
@@ -26,3 +36,75 @@ func (h Healthz) Handle(c *echo.Context) error {
 func (h Healthz) Register(g *echo.Group) {
 	g.GET("/healthz", h.Handle)
 }
+
+// Check returns an error when its dependency is not ready.
+type Check func(ctx context.Context) error
+
+// Readyz checks the dependencies of the server, so the traffic is routed to the server only when
+// it can serve the requests. Each check is canceled after the timeout.
+type Readyz struct {
+	Checks  map[string]Check
+	Timeout time.Duration
+	Logger  *zap.Logger
+	Tracer  trace.Tracer
+}
+
+// Handle runs the checks concurrently and reports each dependency, it responds with 503 when any of them is down.
+// nolint: wrapcheck
+func (h Readyz) Handle(c *echo.Context) error {
+	ctx, span := h.Tracer.Start(c.Request().Context(), "handler.readyz")
+	defer span.End()
+
+	report := response.Readiness{
+		Ready:        true,
+		Dependencies: make(map[string]response.Dependency, len(h.Checks)),
+	}
+
+	var (
+		lock sync.Mutex
+		wg   sync.WaitGroup
+	)
+
+	for name, check := range h.Checks {
+		wg.Go(func() {
+			ctx, cancel := context.WithTimeout(ctx, h.Timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check(ctx)
+
+			dependency := response.Dependency{
+				Status:    StatusUp,
+				LatencyMS: time.Since(start).Milliseconds(),
+				Error:     "",
+			}
+
+			if err != nil {
+				span.RecordError(err)
+				h.Logger.Warn("dependency is not ready", zap.String("dependency", name), zap.Error(err))
+
+				dependency.Status = StatusDown
+				dependency.Error = err.Error()
+			}
+
+			lock.Lock()
+			defer lock.Unlock()
+
+			report.Dependencies[name] = dependency
+			report.Ready = report.Ready && err == nil
+		})
+	}
+
+	wg.Wait()
+
+	if !report.Ready {
+		return c.JSON(http.StatusServiceUnavailable, report)
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// Register registers the routes of readyz handler on given echo group.
+func (h Readyz) Register(g *echo.Group) {
+	g.GET("/readyz", h.Handle)
+}
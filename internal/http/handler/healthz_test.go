@@ -2,11 +2,16 @@ package handler_test
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/1995parham-teaching/fandogh/internal/http/handler"
+	"github.com/1995parham-teaching/fandogh/internal/http/response"
 	"github.com/labstack/echo/v5"
 	"github.com/stretchr/testify/suite"
 	"go.opentelemetry.io/otel/trace"
@@ -58,6 +63,78 @@ func (suite *HealthzSuite) TestHandler() {
 	require.Equal(http.StatusNoContent, w.Code)
 }
 
+func (suite *HealthzSuite) TestReadyz() {
+	require := suite.Require()
+
+	up := func(context.Context) error { return nil }
+	down := func(context.Context) error { return errors.New("connection refused") }
+	slow := func(ctx context.Context) error {
+		<-ctx.Done()
+
+		return ctx.Err()
+	}
+
+	cases := []struct {
+		name   string
+		checks map[string]handler.Check
+		code   int
+		down   []string
+	}{
+		{
+			name:   "Ready",
+			checks: map[string]handler.Check{"mongodb": up, "storage": up},
+			code:   http.StatusOK,
+			down:   nil,
+		},
+		{
+			name:   "Unreachable",
+			checks: map[string]handler.Check{"mongodb": up, "storage": down},
+			code:   http.StatusServiceUnavailable,
+			down:   []string{"storage"},
+		},
+		{
+			name:   "Timeout",
+			checks: map[string]handler.Check{"mongodb": slow, "storage": up},
+			code:   http.StatusServiceUnavailable,
+			down:   []string{"mongodb"},
+		},
+	}
+
+	for _, c := range cases {
+		suite.Run(c.name, func() {
+			e := echo.New()
+			handler.Readyz{
+				Checks:  c.checks,
+				Timeout: 10 * time.Millisecond,
+				Logger:  zap.NewNop(),
+				Tracer:  noop.NewTracerProvider().Tracer(""),
+			}.Register(e.Group(""))
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, "/readyz", nil)
+
+			e.ServeHTTP(w, req)
+			require.Equal(c.code, w.Code)
+
+			var report response.Readiness
+
+			require.NoError(json.Unmarshal(w.Body.Bytes(), &report))
+			require.Equal(c.down == nil, report.Ready)
+			require.Len(report.Dependencies, len(c.checks))
+
+			for name, dependency := range report.Dependencies {
+				if slices.Contains(c.down, name) {
+					require.Equal(handler.StatusDown, dependency.Status)
+					require.NotEmpty(dependency.Error)
+				} else {
+					require.Equal(handler.StatusUp, dependency.Status)
+					require.Empty(dependency.Error)
+				}
+			}
+		})
+	}
+}
+
 func TestHealthzSuite(t *testing.T) {
 	t.Parallel()
 	suite.Run(t, new(HealthzSuite))
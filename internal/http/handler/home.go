@@ -1,17 +1,25 @@
 package handler
 
 import (
-	"encoding/base64"
+	"context"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"github.com/1995parham-teaching/fandogh/internal/fs"
 	"github.com/1995parham-teaching/fandogh/internal/http/common"
 	intjwt "github.com/1995parham-teaching/fandogh/internal/http/jwt"
 	"github.com/1995parham-teaching/fandogh/internal/http/request"
+	"github.com/1995parham-teaching/fandogh/internal/http/response"
+	"github.com/1995parham-teaching/fandogh/internal/imaging"
+	"github.com/1995parham-teaching/fandogh/internal/metric"
 	"github.com/1995parham-teaching/fandogh/internal/model"
 	"github.com/1995parham-teaching/fandogh/internal/store/home"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v5"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
@@ -23,19 +31,43 @@ const (
 	maxLimit     = 100
 )
 
+// Conditional request headers, home versions are used as their entity tags.
+const (
+	headerETag    = "ETag"
+	headerIfMatch = "If-Match"
+)
+
 type Home struct {
-	Store  home.Home
-	Tracer trace.Tracer
-	Logger *zap.Logger
+	Store   home.Home
+	Storage fs.Storage
+	Tracer  trace.Tracer
+	Logger  *zap.Logger
+	Metrics metric.Home
 }
 
 // New creates a home based on user request.
-// Accepts JSON body with optional base64-encoded photos.
+// Accepts JSON body with optional base64-encoded photos or a multipart form with optional photo files.
 // nolint: wrapcheck, funlen, cyclop
 func (h Home) New(c *echo.Context) error {
 	ctx, span := h.Tracer.Start(c.Request().Context(), "handler.home.create")
 	defer span.End()
 
+	var photos []model.Photo
+
+	// the photo files are read before the other fields of the form, the home has no id yet,
+	// so they are staged as the uploads of a random one.
+	if isMultipart(c) {
+		staged, err := h.stagePhotos(ctx, c, uuid.New().String())
+		if err != nil {
+			span.RecordError(err)
+
+			return err
+		}
+
+		photos = staged
+		defer closePhotos(staged)
+	}
+
 	var rq request.NewHome
 
 	if err := c.Bind(&rq); err != nil {
@@ -62,37 +94,18 @@ func (h Home) New(c *echo.Context) error {
 		return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
 	}
 
-	var bed model.Bed
-
-	switch rq.Bed {
-	case "single":
-		bed = model.Single
-	case "double":
-		bed = model.Double
-	default:
+	bed, ok := parseBed(rq.Bed)
+	if !ok {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid bed type")
 	}
 
-	// Decode base64 photos
-	photos := make([]model.Photo, 0, len(rq.Photos))
-
-	for _, p := range rq.Photos {
-		if p.Name == "" || p.Content == "" {
-			continue
-		}
-
-		data, err := base64.StdEncoding.DecodeString(p.Content)
+	if !isMultipart(c) {
+		photos, err = decodePhotos(rq.Photos)
 		if err != nil {
 			span.RecordError(err)
 
-			return echo.NewHTTPError(http.StatusBadRequest, "invalid base64 encoding for photo: "+p.Name)
+			return err
 		}
-
-		photos = append(photos, model.Photo{
-			Name:        p.Name,
-			ContentType: http.DetectContentType(data),
-			Content:     data,
-		})
 	}
 
 	m := model.Home{
@@ -113,16 +126,23 @@ func (h Home) New(c *echo.Context) error {
 		Contract:        rq.Contract,
 		SecurityDeposit: rq.SecurityDeposit,
 		Photos:          nil,
+		Thumbnails:      nil,
+		PhotoOrder:      nil,
+		Cover:           "",
 		Price:           rq.Price,
+		Version:         0,
 	}
 
 	if err := h.Store.Set(ctx, &m, photos); err != nil {
 		span.RecordError(err)
 
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return storeError(err)
 	}
 
-	return c.JSON(http.StatusCreated, m)
+	h.Metrics.Created.Inc()
+	h.Metrics.Uploaded(photosSize(photos))
+
+	return h.respond(ctx, c, http.StatusCreated, m)
 }
 
 // Get retrieves a home by its ID.
@@ -147,15 +167,29 @@ func (h Home) Get(c *echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
-	return c.JSON(http.StatusOK, m)
+	return h.respond(ctx, c, http.StatusOK, m)
 }
 
-// List retrieves homes with pagination.
+// List retrieves homes matching the query filters with pagination.
 // nolint: wrapcheck
 func (h Home) List(c *echo.Context) error {
 	ctx, span := h.Tracer.Start(c.Request().Context(), "handler.home.list")
 	defer span.End()
 
+	var rq request.HomeFilter
+
+	if err := echo.BindQueryParams(c, &rq); err != nil {
+		span.RecordError(err)
+
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := rq.Validate(); err != nil {
+		span.RecordError(err)
+
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
 	skip := int64(0)
 	limit := int64(defaultLimit)
 
@@ -171,14 +205,43 @@ func (h Home) List(c *echo.Context) error {
 		}
 	}
 
-	result, err := h.Store.List(ctx, skip, limit)
+	result, err := h.Store.List(ctx, home.Query{
+		Filter: filter(rq),
+		Sort:   order(rq),
+		Cursor: rq.Cursor,
+		Skip:   skip,
+		Limit:  limit,
+	})
 	if err != nil {
 		span.RecordError(err)
 
+		if errors.Is(err, home.ErrInvalidCursor) || errors.Is(err, home.ErrCursorWithSkip) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
-	return c.JSON(http.StatusOK, result)
+	homes := response.Homes{
+		Homes:      make([]response.Home, 0, len(result.Homes)),
+		Total:      result.Total,
+		Skip:       result.Skip,
+		Limit:      result.Limit,
+		NextCursor: result.NextCursor,
+	}
+
+	for _, m := range result.Homes {
+		rsp, err := h.present(ctx, m)
+		if err != nil {
+			span.RecordError(err)
+
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		homes.Homes = append(homes.Homes, rsp)
+	}
+
+	return c.JSON(http.StatusOK, homes)
 }
 
 // Update modifies an existing home. Only the owner or an admin can update.
@@ -192,6 +255,11 @@ func (h Home) Update(c *echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "home id is required")
 	}
 
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+
 	// Get the existing home to check ownership
 	existingHome, err := h.Store.Get(ctx, id)
 	if err != nil {
@@ -204,27 +272,10 @@ func (h Home) Update(c *echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
-	// Get JWT claims
-	token, ok := c.Get(common.UserContextKey).(*jwt.Token)
-	if !ok {
-		return echo.NewHTTPError(http.StatusUnauthorized, "user claims not found")
-	}
-
-	claims, ok := token.Claims.(*intjwt.Claims)
-	if !ok {
-		return echo.NewHTTPError(http.StatusUnauthorized, "invalid token claims")
-	}
-
-	sub, err := claims.GetSubject()
-	if err != nil {
+	if err := authorize(c, existingHome, "update"); err != nil {
 		span.RecordError(err)
 
-		return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
-	}
-
-	// Check authorization: must be owner or admin
-	if existingHome.Owner != sub && !claims.Admin {
-		return echo.NewHTTPError(http.StatusForbidden, "only the owner or an admin can update this home")
+		return err
 	}
 
 	// Bind and validate request
@@ -242,14 +293,8 @@ func (h Home) Update(c *echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	var bed model.Bed
-
-	switch rq.Bed {
-	case "single":
-		bed = model.Single
-	case "double":
-		bed = model.Double
-	default:
+	bed, ok := parseBed(rq.Bed)
+	if !ok {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid bed type")
 	}
 
@@ -271,16 +316,347 @@ func (h Home) Update(c *echo.Context) error {
 		Contract:        rq.Contract,
 		SecurityDeposit: rq.SecurityDeposit,
 		Photos:          existingHome.Photos,
+		Thumbnails:      existingHome.Thumbnails,
+		PhotoOrder:      existingHome.PhotoOrder,
+		Cover:           existingHome.Cover,
+		Price:           rq.Price,
+		Version:         version + 1,
+	}
+
+	if err := h.Store.Update(ctx, id, version, updatedHome); err != nil {
+		span.RecordError(err)
+
+		return storeError(err)
+	}
+
+	h.Metrics.Updated.Inc()
+
+	return h.respond(ctx, c, http.StatusOK, updatedHome)
+}
+
+// Patch changes the given fields of an existing home with a JSON merge patch (RFC 7396).
+// Only the owner or an admin can patch.
+// nolint: wrapcheck, cyclop, funlen
+func (h Home) Patch(c *echo.Context) error {
+	ctx, span := h.Tracer.Start(c.Request().Context(), "handler.home.patch")
+	defer span.End()
+
+	id := c.Param("id")
+	if id == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "home id is required")
+	}
+
+	mime, _, _ := strings.Cut(c.Request().Header.Get(echo.HeaderContentType), ";")
+	if mime != request.MIMEApplicationMergePatch && mime != echo.MIMEApplicationJSON {
+		return echo.NewHTTPError(http.StatusUnsupportedMediaType, "home patch must be a json merge patch")
+	}
+
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+
+	existingHome, err := h.Store.Get(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+
+		if errors.Is(err, home.ErrIDNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if err := authorize(c, existingHome, "update"); err != nil {
+		span.RecordError(err)
+
+		return err
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		span.RecordError(err)
+
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	rq, err := request.DecodePatchHome(body)
+	if err != nil {
+		span.RecordError(err)
+
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := rq.Validate(); err != nil {
+		span.RecordError(err)
+
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	patch := home.Patch{
+		Title:           rq.Title,
+		Location:        rq.Location,
+		Description:     rq.Description,
+		Peoples:         rq.Peoples,
+		Room:            rq.Room,
+		Bed:             nil,
+		Rooms:           rq.Rooms,
+		Bathrooms:       rq.Bathrooms,
+		Smoking:         rq.Smoking,
+		Guest:           rq.Guest,
+		Pet:             rq.Pet,
+		BillsIncluded:   rq.BillsIncluded,
+		Contract:        rq.Contract,
+		SecurityDeposit: rq.SecurityDeposit,
 		Price:           rq.Price,
 	}
 
-	if err := h.Store.Update(ctx, id, updatedHome); err != nil {
+	if rq.Bed != nil {
+		bed, _ := parseBed(*rq.Bed)
+		patch.Bed = &bed
+	}
+
+	patchedHome, err := h.Store.Patch(ctx, id, version, patch)
+	if err != nil {
 		span.RecordError(err)
 
+		return storeError(err)
+	}
+
+	h.Metrics.Updated.Inc()
+
+	return h.respond(ctx, c, http.StatusOK, patchedHome)
+}
+
+// Delete removes an existing home with its photos. Only the owner or an admin can delete.
+// nolint: wrapcheck
+func (h Home) Delete(c *echo.Context) error {
+	ctx, span := h.Tracer.Start(c.Request().Context(), "handler.home.delete")
+	defer span.End()
+
+	id := c.Param("id")
+	if id == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "home id is required")
+	}
+
+	existingHome, err := h.Store.Get(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+
+		if errors.Is(err, home.ErrIDNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if err := authorize(c, existingHome, "delete"); err != nil {
+		span.RecordError(err)
+
+		return err
+	}
+
+	if err := h.Store.Delete(ctx, id); err != nil {
+		span.RecordError(err)
+
+		if errors.Is(err, home.ErrIDNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	h.Logger.Info("home is deleted", zap.String("id", id))
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// filter converts the validated list filters of the request into the store filter.
+func filter(rq request.HomeFilter) home.Filter {
+	f := home.Filter{
+		MinPrice:           rq.MinPrice,
+		MaxPrice:           rq.MaxPrice,
+		MinSecurityDeposit: rq.MinSecurityDeposit,
+		MaxSecurityDeposit: rq.MaxSecurityDeposit,
+		MinRooms:           rq.MinRooms,
+		MinBathrooms:       rq.MinBathrooms,
+		MinPeoples:         rq.MinPeoples,
+		Bed:                nil,
+		Location:           "",
+		Smoking:            rq.Smoking,
+		Guest:              rq.Guest,
+		Pet:                rq.Pet,
+		BillsIncluded:      rq.BillsIncluded,
+	}
+
+	if rq.Bed != nil {
+		if bed, ok := parseBed(*rq.Bed); ok {
+			f.Bed = &bed
+		}
+	}
+
+	if rq.Location != nil {
+		f.Location = strings.TrimSpace(*rq.Location)
+	}
+
+	return f
+}
+
+// order converts the validated sort of the request into the store sort.
+func order(rq request.HomeFilter) home.Sort {
+	field, descending := rq.SortBy()
+
+	s := home.Sort{
+		Field:      home.SortCreation,
+		Descending: descending,
+	}
+
+	switch field {
+	case "price":
+		s.Field = home.SortPrice
+	case "security_deposit":
+		s.Field = home.SortSecurityDeposit
+	case "rooms":
+		s.Field = home.SortRooms
+	}
+
+	return s
+}
+
+// parseBed converts the bed type of the requests into the model.
+func parseBed(bed string) (model.Bed, bool) {
+	switch bed {
+	case "single":
+		return model.Single, true
+	case "double":
+		return model.Double, true
+	default:
+		return 0, false
+	}
+}
+
+// respond writes the given home with its photo URLs and its version as the ETag.
+// nolint: wrapcheck
+func (h Home) respond(ctx context.Context, c *echo.Context, code int, m model.Home) error {
+	rsp, err := h.present(ctx, m)
+	if err != nil {
+		trace.SpanFromContext(ctx).RecordError(err)
+
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
-	return c.JSON(http.StatusOK, updatedHome)
+	c.Response().Header().Set(headerETag, etag(m.Version))
+
+	return c.JSON(code, rsp)
+}
+
+// present adds the presigned download URLs of the photos and their thumbnails to the given home.
+func (h Home) present(ctx context.Context, m model.Home) (response.Home, error) {
+	rsp := response.Home{
+		Home:          m,
+		PhotoURLs:     make(map[string]string, len(m.Photos)),
+		ThumbnailURLs: make(map[string]map[string]string, len(m.Thumbnails)),
+	}
+
+	for name, key := range m.Photos {
+		url, err := h.Storage.PresignGet(ctx, home.Bucket, key)
+		if err != nil {
+			return rsp, fmt.Errorf("cannot create photo url: %w", err)
+		}
+
+		rsp.PhotoURLs[name] = url
+	}
+
+	for name, thumbnails := range m.Thumbnails {
+		rsp.ThumbnailURLs[name] = make(map[string]string, len(thumbnails))
+
+		for size, key := range thumbnails {
+			url, err := h.Storage.PresignGet(ctx, home.Bucket, key)
+			if err != nil {
+				return rsp, fmt.Errorf("cannot create thumbnail url: %w", err)
+			}
+
+			rsp.ThumbnailURLs[name][size] = url
+		}
+	}
+
+	return rsp, nil
+}
+
+// etag returns the entity tag of the given home version.
+func etag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// ifMatch returns the home version from the If-Match header, which is required for changing a home.
+// nolint: wrapcheck
+func ifMatch(c *echo.Context) (int64, error) {
+	header := c.Request().Header.Get(headerIfMatch)
+	if header == "" {
+		return 0, echo.NewHTTPError(http.StatusPreconditionRequired, "If-Match header with the home ETag is required")
+	}
+
+	value, err := strconv.Unquote(strings.TrimSpace(header))
+	if err != nil {
+		return 0, echo.NewHTTPError(http.StatusPreconditionFailed, "If-Match must be a single strong ETag")
+	}
+
+	version, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, echo.NewHTTPError(http.StatusPreconditionFailed, "If-Match does not match the home ETag")
+	}
+
+	return version, nil
+}
+
+// storeError converts the errors of changing a home into HTTP errors.
+// nolint: wrapcheck
+func storeError(err error) error {
+	switch {
+	case errors.Is(err, home.ErrIDNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, home.ErrVersionMismatch):
+		return echo.NewHTTPError(http.StatusPreconditionFailed, err.Error())
+	case errors.Is(err, home.ErrPhotoNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, home.ErrPhotoExists):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	case errors.Is(err, home.ErrInvalidPhotoOrder), errors.Is(err, home.ErrPhotoNotUploaded):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case errors.Is(err, home.ErrPhotoTooLarge):
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, imaging.ErrNotImage):
+		return echo.NewHTTPError(http.StatusUnsupportedMediaType, err.Error())
+	case errors.Is(err, imaging.ErrDimensions):
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	default:
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+}
+
+// authorize checks the user of the request is the owner of the given home or an admin.
+// nolint: wrapcheck
+func authorize(c *echo.Context, m model.Home, action string) error {
+	token, ok := c.Get(common.UserContextKey).(*jwt.Token)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user claims not found")
+	}
+
+	claims, ok := token.Claims.(*intjwt.Claims)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid token claims")
+	}
+
+	sub, err := claims.GetSubject()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+	}
+
+	if m.Owner != sub && !claims.Admin {
+		return echo.NewHTTPError(http.StatusForbidden, "only the owner or an admin can "+action+" this home")
+	}
+
+	return nil
 }
 
 func (h Home) Register(g *echo.Group) {
@@ -288,4 +664,12 @@ func (h Home) Register(g *echo.Group) {
 	g.GET("/homes", h.List)
 	g.GET("/homes/:id", h.Get)
 	g.PUT("/homes/:id", h.Update)
+	g.PATCH("/homes/:id", h.Patch)
+	g.DELETE("/homes/:id", h.Delete)
+	g.POST("/homes/:id/photos", h.AddPhotos)
+	g.DELETE("/homes/:id/photos/:name", h.DeletePhoto)
+	g.PUT("/homes/:id/photos/order", h.OrderPhotos)
+	g.PUT("/homes/:id/photos/cover", h.SetCover)
+	g.POST("/homes/:id/photos/uploads", h.NewUpload)
+	g.POST("/homes/:id/photos/uploads/confirm", h.ConfirmUpload)
 }
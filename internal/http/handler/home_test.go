@@ -0,0 +1,329 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1995parham-teaching/fandogh/internal/fs"
+	"github.com/1995parham-teaching/fandogh/internal/http/handler"
+	"github.com/1995parham-teaching/fandogh/internal/http/jwt"
+	"github.com/1995parham-teaching/fandogh/internal/http/request"
+	"github.com/1995parham-teaching/fandogh/internal/http/response"
+	"github.com/1995parham-teaching/fandogh/internal/imaging"
+	"github.com/1995parham-teaching/fandogh/internal/metric"
+	"github.com/1995parham-teaching/fandogh/internal/model"
+	"github.com/1995parham-teaching/fandogh/internal/store/denylist"
+	"github.com/1995parham-teaching/fandogh/internal/store/home"
+	"github.com/labstack/echo/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+)
+
+type HomeSuite struct {
+	suite.Suite
+
+	jwt     jwt.JWT
+	engine  *echo.Echo
+	metrics metric.Home
+	storage fs.Storage
+}
+
+func (suite *HomeSuite) SetupSuite() {
+	require := suite.Require()
+
+	storage, err := fs.NewLocal(fs.Config{
+		Driver:        fs.DriverLocal,
+		Endpoint:      "",
+		AccessKey:     "",
+		SecretKey:     "",
+		UseSSL:        false,
+		Region:        "",
+		PresignExpiry: time.Hour,
+		Local: fs.LocalConfig{
+			Root:   suite.T().TempDir(),
+			URL:    "",
+			Secret: "secret",
+		},
+	})
+	require.NoError(err)
+
+	cursors, err := home.NewCursors(home.Config{CursorSecret: "secret"})
+	require.NoError(err)
+
+	processor, err := imaging.NewProcessor(imaging.Config{
+		MaxWidth:   4096,
+		MaxHeight:  4096,
+		Thumbnails: map[string]int{"small": 320},
+	})
+	require.NoError(err)
+
+	// nolint: exhaustruct
+	jwtHandler, err := jwt.Provide(jwt.Config{
+		AccessTokenSecret: "secret",
+		RefreshTokenTTL:   time.Hour,
+	}, denylist.NewMemoryDenylist())
+	require.NoError(err)
+
+	metrics, err := metric.NewHome(prometheus.NewRegistry())
+	require.NoError(err)
+
+	engine := echo.New()
+
+	handler.Home{
+		Store:   home.NewMemoryHome(storage, cursors, processor, 2),
+		Storage: storage,
+		Tracer:  noop.NewTracerProvider().Tracer(""),
+		Logger:  zap.NewNop(),
+		Metrics: metrics,
+	}.Register(engine.Group("/api", jwtHandler.Middleware()))
+
+	suite.jwt = jwtHandler
+	suite.engine = engine
+	suite.metrics = metrics
+	suite.storage = storage
+}
+
+// photo returns a PNG image with a single pixel.
+func photo() []byte {
+	var buf bytes.Buffer
+
+	_ = png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 1, 1)))
+
+	return buf.Bytes()
+}
+
+// send sends the given body as JSON with the access token of the owner and returns the response.
+func (suite *HomeSuite) send(method string, path string, version int64, body any) *httptest.ResponseRecorder {
+	b, err := json.Marshal(body)
+	suite.Require().NoError(err)
+
+	token, err := suite.jwt.NewAccessToken(model.User{
+		Email:          parhamEmail,
+		Password:       "",
+		Name:           parhamName,
+		Admin:          false,
+		LegacyPassword: false,
+	})
+	suite.Require().NoError(err)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(context.Background(), method, path, bytes.NewReader(b))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+
+	if version != 0 {
+		req.Header.Set("If-Match", strconv.Quote(strconv.FormatInt(version, 10)))
+	}
+
+	suite.engine.ServeHTTP(w, req)
+
+	return w
+}
+
+// file is a file of a multipart form.
+type file struct {
+	name    string
+	content []byte
+}
+
+// sendForm sends the given fields and photo files as a multipart form with the access token of the owner
+// and returns the response.
+func (suite *HomeSuite) sendForm(
+	method string, path string, version int64, fields map[string]string, files []file,
+) *httptest.ResponseRecorder {
+	require := suite.Require()
+
+	var body bytes.Buffer
+
+	form := multipart.NewWriter(&body)
+
+	for name, value := range fields {
+		require.NoError(form.WriteField(name, value))
+	}
+
+	for _, f := range files {
+		w, err := form.CreateFormFile("photos", f.name)
+		require.NoError(err)
+
+		_, err = w.Write(f.content)
+		require.NoError(err)
+	}
+
+	require.NoError(form.Close())
+
+	token, err := suite.jwt.NewAccessToken(model.User{
+		Email:          parhamEmail,
+		Password:       "",
+		Name:           parhamName,
+		Admin:          false,
+		LegacyPassword: false,
+	})
+	require.NoError(err)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(context.Background(), method, path, &body)
+	req.Header.Set(echo.HeaderContentType, form.FormDataContentType())
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+
+	if version != 0 {
+		req.Header.Set("If-Match", strconv.Quote(strconv.FormatInt(version, 10)))
+	}
+
+	suite.engine.ServeHTTP(w, req)
+
+	return w
+}
+
+// uploads returns the keys of the uploads in the storage.
+func (suite *HomeSuite) uploads() []string {
+	keys := make([]string, 0)
+
+	for entry, err := range suite.storage.List(context.Background(), home.Bucket, "") {
+		suite.Require().NoError(err)
+
+		if strings.Contains(entry.Key, "/uploads/") {
+			keys = append(keys, entry.Key)
+		}
+	}
+
+	return keys
+}
+
+func (suite *HomeSuite) TestMultipart() {
+	require := suite.Require()
+
+	fields := map[string]string{
+		"title":            "Multipart Home",
+		"location":         "Iran, Tehran",
+		"description":      "Home Sweet Home",
+		"peoples":          "2",
+		"room":             "room_type",
+		"bed":              "single",
+		"rooms":            "1",
+		"bathrooms":        "1",
+		"contract":         "contract_type",
+		"security_deposit": "1000",
+		"price":            "1000",
+	}
+
+	w := suite.sendForm(http.MethodPost, "/api/homes", 0, fields, []file{{name: "1.png", content: photo()}})
+	require.Equal(http.StatusCreated, w.Code, w.Body.String())
+
+	var h response.Home
+
+	require.NoError(json.Unmarshal(w.Body.Bytes(), &h))
+	require.Equal("Multipart Home", h.Title)
+	require.Len(h.Photos, 1)
+
+	path := "/api/homes/" + h.ID + "/photos"
+
+	w = suite.sendForm(http.MethodPost, path, h.Version, nil, []file{{name: "2.png", content: photo()}})
+	require.Equal(http.StatusOK, w.Code, w.Body.String())
+
+	require.NoError(json.Unmarshal(w.Body.Bytes(), &h))
+	require.Len(h.Photos, 2)
+
+	// the upload of the larger photo is aborted and the photos before it are removed.
+	large := []file{{name: "3.png", content: photo()}, {name: "4.png", content: make([]byte, home.MaxPhotoSize+1)}}
+
+	w = suite.sendForm(http.MethodPost, path, h.Version, nil, large)
+	require.Equal(http.StatusRequestEntityTooLarge, w.Code, w.Body.String())
+
+	require.Empty(suite.uploads())
+}
+
+// nolint: funlen
+func (suite *HomeSuite) TestMetrics() {
+	require := suite.Require()
+
+	created := testutil.ToFloat64(suite.metrics.Created)
+	updated := testutil.ToFloat64(suite.metrics.Updated)
+	uploaded := testutil.ToFloat64(suite.metrics.UploadedBytes)
+
+	content := base64.StdEncoding.EncodeToString(photo())
+
+	rq := request.NewHome{
+		Title:           "127.0.0.1",
+		Location:        "Iran, Tehran",
+		Description:     "Home Sweet Home",
+		Peoples:         4,
+		Room:            "room_type",
+		Bed:             "single",
+		Rooms:           1,
+		Bathrooms:       1,
+		Smoking:         false,
+		Guest:           false,
+		Pet:             false,
+		BillsIncluded:   true,
+		Contract:        "contract_type",
+		SecurityDeposit: 1000,
+		Price:           1000,
+		Photos:          []request.PhotoInput{{Name: "1.png", Content: content}},
+	}
+
+	w := suite.send(http.MethodPost, "/api/homes", 0, rq)
+	require.Equal(http.StatusCreated, w.Code)
+
+	var h response.Home
+
+	require.NoError(json.Unmarshal(w.Body.Bytes(), &h))
+
+	// the invalid home is not counted.
+	rq.Title = ""
+	require.Equal(http.StatusBadRequest, suite.send(http.MethodPost, "/api/homes", 0, rq).Code)
+
+	require.InDelta(created+1, testutil.ToFloat64(suite.metrics.Created), 0)
+	require.InDelta(uploaded+float64(len(photo())), testutil.ToFloat64(suite.metrics.UploadedBytes), 0)
+
+	update := request.UpdateHome{
+		Title:           "Home Sweet Home",
+		Location:        "Iran, Tehran",
+		Description:     "Home Sweet Home",
+		Peoples:         4,
+		Room:            "room_type",
+		Bed:             "double",
+		Rooms:           2,
+		Bathrooms:       1,
+		Smoking:         false,
+		Guest:           false,
+		Pet:             false,
+		BillsIncluded:   true,
+		Contract:        "contract_type",
+		SecurityDeposit: 1000,
+		Price:           2000,
+	}
+
+	path := "/api/homes/" + h.ID
+
+	require.Equal(http.StatusOK, suite.send(http.MethodPut, path, h.Version, update).Code)
+	require.Equal(http.StatusOK, suite.send(http.MethodPatch, path, h.Version+1, map[string]any{"price": 3000}).Code)
+
+	// the stale version is not counted.
+	require.Equal(http.StatusPreconditionFailed, suite.send(http.MethodPut, path, h.Version, update).Code)
+
+	require.InDelta(updated+2, testutil.ToFloat64(suite.metrics.Updated), 0)
+
+	photos := request.NewPhotos{Photos: []request.PhotoInput{{Name: "2.png", Content: content}}}
+
+	require.Equal(http.StatusOK, suite.send(http.MethodPost, path+"/photos", h.Version+2, photos).Code)
+	require.InDelta(uploaded+float64(2*len(photo())), testutil.ToFloat64(suite.metrics.UploadedBytes), 0)
+}
+
+func TestHomeSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(HomeSuite))
+}
@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/1995parham-teaching/fandogh/internal/http/jwt"
+	"github.com/labstack/echo/v5"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// JWKS publishes the public keys, so other services can verify the issued tokens.
+type JWKS struct {
+	JWT    jwt.JWT
+	Tracer trace.Tracer
+}
+
+// Handle returns the public keys in JSON Web Key Set format.
+// nolint: wrapcheck
+func (h JWKS) Handle(c *echo.Context) error {
+	_, span := h.Tracer.Start(c.Request().Context(), "handler.jwks")
+	defer span.End()
+
+	c.Response().Header().Set(echo.HeaderCacheControl, "public, max-age=300")
+
+	return c.JSON(http.StatusOK, h.JWT.JWKS())
+}
+
+// Register registers the routes of JWKS handler on given echo group.
+func (h JWKS) Register(g *echo.Group) {
+	g.GET("/.well-known/jwks.json", h.Handle)
+}
@@ -0,0 +1,543 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/1995parham-teaching/fandogh/internal/fs"
+	"github.com/1995parham-teaching/fandogh/internal/http/request"
+	"github.com/1995parham-teaching/fandogh/internal/http/response"
+	"github.com/1995parham-teaching/fandogh/internal/model"
+	"github.com/1995parham-teaching/fandogh/internal/store/home"
+	"github.com/labstack/echo/v5"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// AddPhotos uploads the given photos and adds them after the existing photos of a home.
+// Only the owner or an admin can add photos.
+// nolint: wrapcheck
+func (h Home) AddPhotos(c *echo.Context) error {
+	ctx, span := h.Tracer.Start(c.Request().Context(), "handler.home.add_photos")
+	defer span.End()
+
+	id, version, err := h.editable(ctx, span, c)
+	if err != nil {
+		return err
+	}
+
+	photos, err := h.requestPhotos(ctx, c, id)
+	if err != nil {
+		span.RecordError(err)
+
+		return err
+	}
+	defer closePhotos(photos)
+
+	if len(photos) == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "at least one photo is required")
+	}
+
+	m, err := h.Store.AddPhotos(ctx, id, version, photos)
+	if err != nil {
+		span.RecordError(err)
+
+		return storeError(err)
+	}
+
+	h.Metrics.Uploaded(photosSize(photos))
+
+	return h.respond(ctx, c, http.StatusOK, m)
+}
+
+// DeletePhoto removes a photo of a home. Only the owner or an admin can remove photos.
+// nolint: wrapcheck
+func (h Home) DeletePhoto(c *echo.Context) error {
+	ctx, span := h.Tracer.Start(c.Request().Context(), "handler.home.delete_photo")
+	defer span.End()
+
+	id, version, err := h.editable(ctx, span, c)
+	if err != nil {
+		return err
+	}
+
+	m, err := h.Store.DeletePhoto(ctx, id, version, c.Param("name"))
+	if err != nil {
+		span.RecordError(err)
+
+		return storeError(err)
+	}
+
+	return h.respond(ctx, c, http.StatusOK, m)
+}
+
+// OrderPhotos changes the display order of the photos of a home. Only the owner or an admin can reorder photos.
+// nolint: wrapcheck
+func (h Home) OrderPhotos(c *echo.Context) error {
+	ctx, span := h.Tracer.Start(c.Request().Context(), "handler.home.order_photos")
+	defer span.End()
+
+	id, version, err := h.editable(ctx, span, c)
+	if err != nil {
+		return err
+	}
+
+	var rq request.PhotoOrder
+
+	if err := c.Bind(&rq); err != nil {
+		span.RecordError(err)
+
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := rq.Validate(); err != nil {
+		span.RecordError(err)
+
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	m, err := h.Store.OrderPhotos(ctx, id, version, rq.Order)
+	if err != nil {
+		span.RecordError(err)
+
+		return storeError(err)
+	}
+
+	return h.respond(ctx, c, http.StatusOK, m)
+}
+
+// SetCover designates a photo of a home as its cover. Only the owner or an admin can change the cover.
+// nolint: wrapcheck
+func (h Home) SetCover(c *echo.Context) error {
+	ctx, span := h.Tracer.Start(c.Request().Context(), "handler.home.set_cover")
+	defer span.End()
+
+	id, version, err := h.editable(ctx, span, c)
+	if err != nil {
+		return err
+	}
+
+	var rq request.Cover
+
+	if err := c.Bind(&rq); err != nil {
+		span.RecordError(err)
+
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := rq.Validate(); err != nil {
+		span.RecordError(err)
+
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	m, err := h.Store.SetCover(ctx, id, version, rq.Name)
+	if err != nil {
+		span.RecordError(err)
+
+		return storeError(err)
+	}
+
+	return h.respond(ctx, c, http.StatusOK, m)
+}
+
+// NewUpload creates a presigned URL for uploading a photo directly to the storage,
+// the photo is added to the home after its upload is confirmed. Only the owner or an admin can upload photos.
+// nolint: wrapcheck
+func (h Home) NewUpload(c *echo.Context) error {
+	ctx, span := h.Tracer.Start(c.Request().Context(), "handler.home.new_upload")
+	defer span.End()
+
+	id := c.Param("id")
+	if id == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "home id is required")
+	}
+
+	m, err := h.Store.Get(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+
+		return storeError(err)
+	}
+
+	if err := authorize(c, m, "update"); err != nil {
+		span.RecordError(err)
+
+		return err
+	}
+
+	var rq request.NewUpload
+
+	if err := c.Bind(&rq); err != nil {
+		span.RecordError(err)
+
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := rq.Validate(); err != nil {
+		span.RecordError(err)
+
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if _, ok := m.Photos[rq.Name]; ok {
+		return echo.NewHTTPError(http.StatusConflict, home.ErrPhotoExists.Error())
+	}
+
+	key := fs.GenerateUpload(m.ID, rq.ContentType).String()
+
+	url, err := h.Storage.PresignPut(ctx, home.Bucket, key, rq.ContentType)
+	if err != nil {
+		span.RecordError(err)
+
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusCreated, response.Upload{
+		Name:        rq.Name,
+		Key:         key,
+		URL:         url,
+		ContentType: rq.ContentType,
+		ExpiresAt:   time.Now().Add(h.Storage.PresignExpiry()),
+	})
+}
+
+// ConfirmUpload adds a photo which is uploaded with a presigned URL after the existing photos of a home.
+// Only the owner or an admin can confirm uploads.
+// nolint: wrapcheck
+func (h Home) ConfirmUpload(c *echo.Context) error {
+	ctx, span := h.Tracer.Start(c.Request().Context(), "handler.home.confirm_upload")
+	defer span.End()
+
+	id, version, err := h.editable(ctx, span, c)
+	if err != nil {
+		return err
+	}
+
+	var rq request.ConfirmUpload
+
+	if err := c.Bind(&rq); err != nil {
+		span.RecordError(err)
+
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := rq.Validate(); err != nil {
+		span.RecordError(err)
+
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	m, err := h.Store.ConfirmPhoto(ctx, id, version, rq.Name, rq.Key)
+	if err != nil {
+		span.RecordError(err)
+
+		return storeError(err)
+	}
+
+	return h.respond(ctx, c, http.StatusOK, m)
+}
+
+// requestPhotos reads the photos from the multipart form files or the base64 photos of the JSON body.
+// The multipart form files are staged as uploads of the given home and the photos must be closed by closePhotos.
+// nolint: wrapcheck
+func (h Home) requestPhotos(ctx context.Context, c *echo.Context, id string) ([]model.Photo, error) {
+	if isMultipart(c) {
+		return h.stagePhotos(ctx, c, id)
+	}
+
+	var rq request.NewPhotos
+
+	if err := c.Bind(&rq); err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := rq.Validate(); err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	return decodePhotos(rq.Photos)
+}
+
+// editable returns the id of the requested home and the version from If-Match header,
+// after checking the user of the request can change the home.
+// nolint: wrapcheck
+func (h Home) editable(ctx context.Context, span trace.Span, c *echo.Context) (string, int64, error) {
+	id := c.Param("id")
+	if id == "" {
+		return "", 0, echo.NewHTTPError(http.StatusBadRequest, "home id is required")
+	}
+
+	version, err := ifMatch(c)
+	if err != nil {
+		return "", 0, err
+	}
+
+	m, err := h.Store.Get(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+
+		return "", 0, storeError(err)
+	}
+
+	if err := authorize(c, m, "update"); err != nil {
+		span.RecordError(err)
+
+		return "", 0, err
+	}
+
+	return id, version, nil
+}
+
+// Multipart upload limits, the photo files are streamed to the storage one by one
+// and only the other fields are kept in memory.
+const (
+	maxRequestSize = 50 << 20
+	maxFieldSize   = 1 << 20
+
+	// photosField is the multipart form field of the photo files.
+	photosField = "photos"
+	// sniffLen is the number of bytes which content type detection considers.
+	sniffLen = 512
+)
+
+// isMultipart reports whether the request body is a multipart form.
+func isMultipart(c *echo.Context) bool {
+	mime, _, _ := strings.Cut(c.Request().Header.Get(echo.HeaderContentType), ";")
+
+	return strings.TrimSpace(mime) == echo.MIMEMultipartForm
+}
+
+// stagePhotos streams the photo files of the multipart form within the request size limit to the storage
+// as uploads of the given home and keeps the other fields of the form for binding the request.
+// The staged photos must be closed by closePhotos, which removes them from the storage.
+// nolint: wrapcheck
+func (h Home) stagePhotos(ctx context.Context, c *echo.Context, id string) ([]model.Photo, error) {
+	req := c.Request()
+	req.Body = http.MaxBytesReader(c.Response(), req.Body, maxRequestSize)
+
+	reader, err := req.MultipartReader()
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	photos := make([]model.Photo, 0)
+	values := make(map[string][]string)
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			closePhotos(photos)
+
+			return nil, multipartError(err, "")
+		}
+
+		switch {
+		case part.FileName() == "":
+			value, err := io.ReadAll(io.LimitReader(part, maxFieldSize+1))
+			if err != nil {
+				closePhotos(photos)
+
+				return nil, multipartError(err, "")
+			}
+
+			if len(value) > maxFieldSize {
+				closePhotos(photos)
+
+				return nil, echo.NewHTTPError(http.StatusRequestEntityTooLarge, "field is larger than the limit: "+part.FormName())
+			}
+
+			values[part.FormName()] = append(values[part.FormName()], string(value))
+		case part.FormName() == photosField:
+			photo, err := h.stagePhoto(ctx, id, part)
+			if err != nil {
+				closePhotos(photos)
+
+				return nil, err
+			}
+
+			photos = append(photos, photo)
+		}
+	}
+
+	// the body is consumed, so binding reads the fields from the form instead of parsing it again.
+	req.MultipartForm = &multipart.Form{Value: values, File: nil}
+
+	return photos, nil
+}
+
+// stagePhoto streams the given photo file to the storage within the photo size limit
+// and detects its content type from its beginning.
+// nolint: wrapcheck
+func (h Home) stagePhoto(ctx context.Context, id string, part *multipart.Part) (model.Photo, error) {
+	file := &limitedFile{reader: io.LimitReader(part, home.MaxPhotoSize+1), size: 0, err: nil}
+	content := bufio.NewReaderSize(file, sniffLen)
+
+	head, err := content.Peek(sniffLen)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return model.Photo{}, multipartError(err, part.FileName())
+	}
+
+	contentType := http.DetectContentType(head)
+	key := fs.GenerateUpload(id, contentType).String()
+
+	if err := h.Storage.Put(ctx, home.Bucket, key, fs.Object{
+		Content:     content,
+		Size:        fs.UnknownSize,
+		ContentType: contentType,
+		Metadata:    fs.Metadata(part.FileName()),
+	}); err != nil {
+		if file.err != nil {
+			return model.Photo{}, multipartError(file.err, part.FileName())
+		}
+
+		return model.Photo{}, echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return model.Photo{
+		Name:        part.FileName(),
+		ContentType: contentType,
+		Content:     &stagedPhoto{ctx: ctx, storage: h.Storage, key: key, content: nil},
+		Size:        file.size,
+	}, nil
+}
+
+// multipartError converts the errors of reading the multipart form into HTTP errors.
+// nolint: wrapcheck
+func multipartError(err error, name string) error {
+	if _, ok := errors.AsType[*http.MaxBytesError](err); ok {
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "request is larger than the upload limit")
+	}
+
+	if errors.Is(err, home.ErrPhotoTooLarge) {
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "photo is larger than the upload limit: "+name)
+	}
+
+	return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+}
+
+// limitedFile reads a multipart form file and fails when it is larger than the photo size limit,
+// so its upload is aborted. It keeps the reading errors to tell them apart from the storage errors.
+type limitedFile struct {
+	reader io.Reader
+	size   int64
+	err    error
+}
+
+// nolint: wrapcheck
+func (f *limitedFile) Read(p []byte) (int, error) {
+	n, err := f.reader.Read(p)
+	f.size += int64(n)
+
+	if f.size > home.MaxPhotoSize {
+		f.err = home.ErrPhotoTooLarge
+
+		return n, f.err
+	}
+
+	if err != nil && !errors.Is(err, io.EOF) {
+		f.err = err
+	}
+
+	return n, err
+}
+
+// stagedPhoto is a photo which is staged in the storage. It is read from the storage on its first read,
+// so only the photos which are being processed are open, and it is removed from the storage on its close.
+// The staged photos which are not removed are orphans, so the garbage collection removes them.
+type stagedPhoto struct {
+	ctx     context.Context // nolint: containedctx
+	storage fs.Storage
+	key     string
+	content io.ReadCloser
+}
+
+// nolint: wrapcheck
+func (p *stagedPhoto) Read(b []byte) (int, error) {
+	if p.content == nil {
+		content, _, err := p.storage.Get(p.ctx, home.Bucket, p.key)
+		if err != nil {
+			return 0, fmt.Errorf("staged photo reading failed: %w", err)
+		}
+
+		p.content = content
+	}
+
+	return p.content.Read(b)
+}
+
+func (p *stagedPhoto) Close() error {
+	if p.content != nil {
+		_ = p.content.Close()
+	}
+
+	// the photo is removed even when the request is canceled.
+	if err := p.storage.Delete(context.WithoutCancel(p.ctx), home.Bucket, p.key); err != nil {
+		return fmt.Errorf("staged photo removal failed: %w", err)
+	}
+
+	return nil
+}
+
+// photosSize returns the total size of the given photos in bytes.
+func photosSize(photos []model.Photo) int64 {
+	var total int64
+
+	for _, photo := range photos {
+		total += photo.Size
+	}
+
+	return total
+}
+
+// closePhotos closes the photo files which are staged from a multipart form.
+func closePhotos(photos []model.Photo) {
+	for _, photo := range photos {
+		if closer, ok := photo.Content.(io.Closer); ok {
+			_ = closer.Close()
+		}
+	}
+}
+
+// decodePhotos decodes the base64 photos of the request and detects their content type.
+// Photos without name or content are ignored.
+// nolint: wrapcheck
+func decodePhotos(inputs []request.PhotoInput) ([]model.Photo, error) {
+	photos := make([]model.Photo, 0, len(inputs))
+
+	for _, p := range inputs {
+		if p.Name == "" || p.Content == "" {
+			continue
+		}
+
+		data, err := base64.StdEncoding.DecodeString(p.Content)
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "invalid base64 encoding for photo: "+p.Name)
+		}
+
+		if len(data) > home.MaxPhotoSize {
+			return nil, echo.NewHTTPError(http.StatusRequestEntityTooLarge, "photo is larger than the upload limit: "+p.Name)
+		}
+
+		photos = append(photos, model.Photo{
+			Name:        p.Name,
+			ContentType: http.DetectContentType(data),
+			Content:     bytes.NewReader(data),
+			Size:        int64(len(data)),
+		})
+	}
+
+	return photos, nil
+}
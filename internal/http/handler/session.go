@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/1995parham-teaching/fandogh/internal/http/common"
+	intjwt "github.com/1995parham-teaching/fandogh/internal/http/jwt"
+	"github.com/1995parham-teaching/fandogh/internal/http/request"
+	"github.com/1995parham-teaching/fandogh/internal/store/denylist"
+	"github.com/1995parham-teaching/fandogh/internal/store/refresh"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v5"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Session revokes the issued tokens, it must be registered behind the jwt middleware.
+type Session struct {
+	Denylist denylist.Denylist
+	Tokens   refresh.Refresh
+	Tracer   trace.Tracer
+	Logger   *zap.Logger
+}
+
+// Logout revokes the access token of the request and the given refresh token with its family.
+// nolint: wrapcheck
+func (h Session) Logout(c *echo.Context) error {
+	ctx, span := h.Tracer.Start(c.Request().Context(), "handler.session.logout")
+	defer span.End()
+
+	var rq request.Logout
+
+	if err := c.Bind(&rq); err != nil {
+		span.RecordError(err)
+
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	token, ok := c.Get(common.UserContextKey).(*jwt.Token)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user claims not found")
+	}
+
+	claims, ok := token.Claims.(*intjwt.Claims)
+	if !ok || claims.ExpiresAt == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid token claims")
+	}
+
+	if rq.RefreshToken != "" {
+		rt, err := h.Tokens.Get(ctx, refresh.Hash(rq.RefreshToken))
+		if err != nil && !errors.Is(err, refresh.ErrTokenNotFound) {
+			span.RecordError(err)
+
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		if err == nil {
+			if rt.Email != claims.Subject {
+				return echo.NewHTTPError(http.StatusForbidden, "refresh token belongs to another user")
+			}
+
+			if err := h.Tokens.RevokeFamily(ctx, rt.Family); err != nil {
+				span.RecordError(err)
+
+				return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+			}
+		}
+	}
+
+	if err := h.Denylist.Revoke(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+		span.RecordError(err)
+
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RevokeUser revokes all the access and refresh tokens of the given user. Only admins can revoke tokens.
+// nolint: wrapcheck
+func (h Session) RevokeUser(c *echo.Context) error {
+	ctx, span := h.Tracer.Start(c.Request().Context(), "handler.session.revoke_user")
+	defer span.End()
+
+	token, ok := c.Get(common.UserContextKey).(*jwt.Token)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user claims not found")
+	}
+
+	claims, ok := token.Claims.(*intjwt.Claims)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid token claims")
+	}
+
+	if !claims.Admin {
+		return echo.NewHTTPError(http.StatusForbidden, "only an admin can revoke tokens")
+	}
+
+	var rq request.Revoke
+
+	if err := c.Bind(&rq); err != nil {
+		span.RecordError(err)
+
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := rq.Validate(); err != nil {
+		span.RecordError(err)
+
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	// access tokens issued before now are valid at most for their lifetime.
+	if err := h.Denylist.RevokeSubject(ctx, rq.Email, time.Now().Add(intjwt.AccessTokenTTL)); err != nil {
+		span.RecordError(err)
+
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if err := h.Tokens.RevokeUser(ctx, rq.Email); err != nil {
+		span.RecordError(err)
+
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	h.Logger.Info("tokens are revoked", zap.String("email", rq.Email), zap.String("admin", claims.Subject))
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Register registers the routes of Session handler on given group.
+func (h Session) Register(g *echo.Group) {
+	g.POST("/logout", h.Logout)
+	g.POST("/admin/revoke", h.RevokeUser)
+}
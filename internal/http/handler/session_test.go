@@ -0,0 +1,149 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/1995parham-teaching/fandogh/internal/http/handler"
+	"github.com/1995parham-teaching/fandogh/internal/http/jwt"
+	"github.com/1995parham-teaching/fandogh/internal/http/request"
+	"github.com/1995parham-teaching/fandogh/internal/model"
+	"github.com/1995parham-teaching/fandogh/internal/store/denylist"
+	"github.com/1995parham-teaching/fandogh/internal/store/refresh"
+	"github.com/labstack/echo/v5"
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+)
+
+type SessionSuite struct {
+	suite.Suite
+
+	jwt    jwt.JWT
+	engine *echo.Echo
+}
+
+func (suite *SessionSuite) SetupSuite() {
+	var (
+		engine     *echo.Echo
+		jwtHandler jwt.JWT
+	)
+
+	app := fxtest.New(
+		suite.T(),
+		fx.Provide(zap.NewNop),
+		fx.Provide(func() trace.Tracer {
+			return noop.NewTracerProvider().Tracer("")
+		}),
+		fx.Provide(func() jwt.Config {
+			return jwt.Config{
+				AccessTokenSecret: "secret",
+				RefreshTokenTTL:   time.Hour,
+			}
+		}),
+		fx.Provide(
+			fx.Annotate(
+				denylist.NewMemoryDenylist,
+				fx.As(new(denylist.Denylist)),
+			),
+		),
+		fx.Provide(
+			fx.Annotate(
+				refresh.NewMemoryRefresh,
+				fx.As(new(refresh.Refresh)),
+			),
+		),
+		fx.Provide(jwt.Provide),
+		fx.Provide(func(
+			denylistStore denylist.Denylist,
+			refreshStore refresh.Refresh,
+			logger *zap.Logger,
+			tracer trace.Tracer,
+			jwtHandler jwt.JWT,
+		) *echo.Echo {
+			e := echo.New()
+			handler.Session{
+				Denylist: denylistStore,
+				Tokens:   refreshStore,
+				Logger:   logger,
+				Tracer:   tracer,
+			}.Register(e.Group("/api", jwtHandler.Middleware()))
+
+			return e
+		}),
+		fx.Populate(&engine, &jwtHandler),
+	)
+	defer app.RequireStart().RequireStop()
+
+	suite.engine = engine
+	suite.jwt = jwtHandler
+}
+
+func (suite *SessionSuite) token(email string, admin bool) string {
+	token, err := suite.jwt.NewAccessToken(model.User{
+		Email:          email,
+		Password:       "",
+		Name:           "",
+		Admin:          admin,
+		LegacyPassword: false,
+	})
+	suite.Require().NoError(err)
+
+	return token
+}
+
+func (suite *SessionSuite) post(path string, token string, body any) int {
+	b, err := json.Marshal(body)
+	suite.Require().NoError(err)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, path, bytes.NewReader(b))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+
+	suite.engine.ServeHTTP(w, req)
+
+	return w.Code
+}
+
+func (suite *SessionSuite) TestLogout() {
+	require := suite.Require()
+
+	token := suite.token(parhamEmail, false)
+
+	require.Equal(http.StatusNoContent, suite.post("/api/logout", token, request.Logout{RefreshToken: ""}))
+	require.Equal(http.StatusUnauthorized, suite.post("/api/logout", token, request.Logout{RefreshToken: ""}))
+}
+
+func (suite *SessionSuite) TestRevokeUser() {
+	require := suite.Require()
+
+	admin := suite.token(parhamEmail, true)
+	token := suite.token(elaheEmail, false)
+
+	require.Equal(http.StatusForbidden, suite.post("/api/admin/revoke", token, request.Revoke{Email: parhamEmail}))
+
+	// the tokens which are issued in the second of the revocation are kept.
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+
+	require.Equal(http.StatusNoContent, suite.post("/api/admin/revoke", admin, request.Revoke{Email: elaheEmail}))
+	require.Equal(http.StatusUnauthorized, suite.post("/api/logout", token, request.Logout{RefreshToken: ""}))
+
+	// the token of a login right after the revocation is not revoked.
+	token = suite.token(elaheEmail, false)
+
+	require.Equal(http.StatusNoContent, suite.post("/api/logout", token, request.Logout{RefreshToken: ""}))
+}
+
+func TestSessionSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(SessionSuite))
+}
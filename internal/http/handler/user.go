@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -8,7 +9,9 @@ import (
 	"github.com/1995parham-teaching/fandogh/internal/http/jwt"
 	"github.com/1995parham-teaching/fandogh/internal/http/request"
 	"github.com/1995parham-teaching/fandogh/internal/http/response"
+	"github.com/1995parham-teaching/fandogh/internal/metric"
 	"github.com/1995parham-teaching/fandogh/internal/model"
+	"github.com/1995parham-teaching/fandogh/internal/store/refresh"
 	"github.com/1995parham-teaching/fandogh/internal/store/user"
 	"github.com/labstack/echo/v5"
 	"go.opentelemetry.io/otel/trace"
@@ -16,10 +19,12 @@ import (
 )
 
 type User struct {
-	Store  user.User
-	Tracer trace.Tracer
-	Logger *zap.Logger
-	JWT    jwt.JWT
+	Store   user.User
+	Tokens  refresh.Refresh
+	Tracer  trace.Tracer
+	Logger  *zap.Logger
+	JWT     jwt.JWT
+	Metrics metric.User
 }
 
 func (h User) Create(c *echo.Context) error {
@@ -60,6 +65,8 @@ func (h User) Create(c *echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
+	h.Metrics.Registrations.Inc()
+
 	return c.JSON(http.StatusCreated, u)
 }
 
@@ -85,19 +92,23 @@ func (h User) Login(c *echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	u, err := h.Store.Get(ctx, rq.Email)
+	u, err := h.Store.Authenticate(ctx, rq.Email, rq.Password)
 	if err != nil {
 		span.RecordError(err)
 
 		if errors.Is(err, user.ErrEmailNotFound) {
+			h.Metrics.LoggedIn(false)
+
 			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("email %s does not exist", rq.Email))
 		}
 
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
-	}
+		if errors.Is(err, user.ErrPasswordMismatch) {
+			h.Metrics.LoggedIn(false)
+
+			return echo.NewHTTPError(http.StatusUnauthorized, "incorrect password")
+		}
 
-	if u.Password != rq.Password {
-		return echo.NewHTTPError(http.StatusUnauthorized, "incorrect password")
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
 	var res response.Login
@@ -111,11 +122,104 @@ func (h User) Login(c *echo.Context) error {
 
 	res.AccessToken = t
 
+	rt, err := h.newRefreshToken(ctx, u.Email, "")
+	if err != nil {
+		span.RecordError(err)
+
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	res.RefreshToken = rt
+
+	h.Metrics.LoggedIn(true)
+
 	return c.JSON(http.StatusOK, res)
 }
 
+// Refresh rotates the given refresh token and issues a new access token. Reusing a rotated
+// refresh token revokes all the refresh tokens issued from the same login.
+// nolint: wrapcheck
+func (h User) Refresh(c *echo.Context) error {
+	ctx, span := h.Tracer.Start(c.Request().Context(), "handler.user.refresh")
+	defer span.End()
+
+	var rq request.Refresh
+
+	err := c.Bind(&rq)
+	if err != nil {
+		span.RecordError(err)
+
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	err = rq.Validate()
+	if err != nil {
+		span.RecordError(err)
+
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	token, err := h.Tokens.Rotate(ctx, refresh.Hash(rq.RefreshToken))
+	if err != nil {
+		span.RecordError(err)
+
+		if errors.Is(err, refresh.ErrTokenReused) {
+			h.Logger.Warn("refresh token reuse detected", zap.String("email", token.Email), zap.String("family", token.Family))
+		}
+
+		if errors.Is(err, refresh.ErrTokenNotFound) || errors.Is(err, refresh.ErrTokenReused) ||
+			errors.Is(err, refresh.ErrTokenRevoked) || errors.Is(err, refresh.ErrTokenExpired) {
+			return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+		}
+
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	u, err := h.Store.Get(ctx, token.Email)
+	if err != nil {
+		span.RecordError(err)
+
+		if errors.Is(err, user.ErrEmailNotFound) {
+			return echo.NewHTTPError(http.StatusUnauthorized, fmt.Sprintf("email %s does not exist", token.Email))
+		}
+
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	var res response.Token
+
+	res.AccessToken, err = h.JWT.NewAccessToken(u)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	res.RefreshToken, err = h.newRefreshToken(ctx, u.Email, token.Family)
+	if err != nil {
+		span.RecordError(err)
+
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// newRefreshToken issues a refresh token in the given family, an empty family starts a new one.
+func (h User) newRefreshToken(ctx context.Context, email string, family string) (string, error) {
+	token, record, err := refresh.New(email, family, h.JWT.RefreshTokenTTL)
+	if err != nil {
+		return "", fmt.Errorf("refresh token creation failed: %w", err)
+	}
+
+	if err := h.Tokens.Set(ctx, &record); err != nil {
+		return "", fmt.Errorf("refresh token storing failed: %w", err)
+	}
+
+	return token, nil
+}
+
 // Register registers the routes of User handler on given group.
 func (h User) Register(g *echo.Group) {
 	g.POST("/register", h.Create)
 	g.POST("/login", h.Login)
+	g.POST("/token/refresh", h.Refresh)
 }
@@ -7,38 +7,50 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/1995parham-teaching/fandogh/internal/http/handler"
 	"github.com/1995parham-teaching/fandogh/internal/http/jwt"
 	"github.com/1995parham-teaching/fandogh/internal/http/request"
+	"github.com/1995parham-teaching/fandogh/internal/http/response"
+	"github.com/1995parham-teaching/fandogh/internal/metric"
 	"github.com/1995parham-teaching/fandogh/internal/model"
+	"github.com/1995parham-teaching/fandogh/internal/security"
+	"github.com/1995parham-teaching/fandogh/internal/store/denylist"
+	"github.com/1995parham-teaching/fandogh/internal/store/refresh"
 	store "github.com/1995parham-teaching/fandogh/internal/store/user"
 	"github.com/labstack/echo/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/suite"
 	"go.opentelemetry.io/otel/trace"
 	"go.opentelemetry.io/otel/trace/noop"
 	"go.uber.org/fx"
 	"go.uber.org/fx/fxtest"
 	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
 )
 
 const (
 	parhamName  = "Parham Alvani"
 	parhamEmail = "parham.alvani@gmail.com"
 	elaheEmail  = "elahe.dstn@gmail.com"
+	raziehEmail = "razieh.alvani@gmail.com"
 )
 
 type UserSuite struct {
 	suite.Suite
 
-	store  store.User
-	engine *echo.Echo
+	store   store.User
+	engine  *echo.Echo
+	metrics metric.User
 }
 
 func (suite *UserSuite) SetupSuite() {
 	var (
 		engine    *echo.Echo
 		userStore store.User
+		metrics   metric.User
 	)
 
 	app := fxtest.New(
@@ -50,37 +62,65 @@ func (suite *UserSuite) SetupSuite() {
 		fx.Provide(func() jwt.Config {
 			return jwt.Config{
 				AccessTokenSecret: "secret",
+				RefreshTokenTTL:   time.Hour,
 			}
 		}),
+		fx.Provide(
+			fx.Annotate(
+				denylist.NewMemoryDenylist,
+				fx.As(new(denylist.Denylist)),
+			),
+		),
 		fx.Provide(jwt.Provide),
+		fx.Provide(func() (security.Hasher, error) {
+			return security.NewHasher(security.Password{
+				Algorithm:  security.Bcrypt,
+				BcryptCost: bcrypt.MinCost,
+				Argon2:     security.Argon2{Time: 0, Memory: 0, Threads: 0, KeyLength: 0, SaltLength: 0},
+			})
+		}),
 		fx.Provide(
 			fx.Annotate(
 				store.NewMemoryUser,
 				fx.As(new(store.User)),
 			),
 		),
+		fx.Provide(
+			fx.Annotate(
+				refresh.NewMemoryRefresh,
+				fx.As(new(refresh.Refresh)),
+			),
+		),
+		fx.Provide(func() (metric.User, error) {
+			return metric.NewUser(prometheus.NewRegistry())
+		}),
 		fx.Provide(func(
 			userStore store.User,
+			refreshStore refresh.Refresh,
 			logger *zap.Logger,
 			tracer trace.Tracer,
 			jwtHandler jwt.JWT,
+			metrics metric.User,
 		) *echo.Echo {
 			e := echo.New()
 			handler.User{
-				Store:  userStore,
-				Logger: logger,
-				Tracer: tracer,
-				JWT:    jwtHandler,
+				Store:   userStore,
+				Tokens:  refreshStore,
+				Logger:  logger,
+				Tracer:  tracer,
+				JWT:     jwtHandler,
+				Metrics: metrics,
 			}.Register(e.Group(""))
 
 			return e
 		}),
-		fx.Populate(&engine, &userStore),
+		fx.Populate(&engine, &userStore, &metrics),
 	)
 	defer app.RequireStart().RequireStop()
 
 	suite.engine = engine
 	suite.store = userStore
+	suite.metrics = metrics
 }
 
 func (suite *UserSuite) TestBadRequest() {
@@ -113,6 +153,8 @@ func (suite *UserSuite) TestBadRequest() {
 func (suite *UserSuite) TestRegister() {
 	require := suite.Require()
 
+	registrations := testutil.ToFloat64(suite.metrics.Registrations)
+
 	cases := []struct {
 		name     string
 		code     int
@@ -158,6 +200,9 @@ func (suite *UserSuite) TestRegister() {
 			require.Equal(c.code, w.Code)
 		})
 	}
+
+	// only the successful registration is counted.
+	require.InDelta(registrations+1, testutil.ToFloat64(suite.metrics.Registrations), 0)
 }
 
 func (suite *UserSuite) TestLogin() {
@@ -170,6 +215,9 @@ func (suite *UserSuite) TestLogin() {
 		Admin:    false,
 	}))
 
+	succeeded := testutil.ToFloat64(suite.metrics.Logins.WithLabelValues("succeeded"))
+	failed := testutil.ToFloat64(suite.metrics.Logins.WithLabelValues("failed"))
+
 	cases := []struct {
 		name  string
 		code  int
@@ -219,6 +267,75 @@ func (suite *UserSuite) TestLogin() {
 			require.Equal(c.code, w.Code)
 		})
 	}
+
+	// the invalid requests are not counted as the failed logins.
+	require.InDelta(succeeded+1, testutil.ToFloat64(suite.metrics.Logins.WithLabelValues("succeeded")), 0)
+	require.InDelta(failed+2, testutil.ToFloat64(suite.metrics.Logins.WithLabelValues("failed")), 0)
+}
+
+func (suite *UserSuite) TestRefresh() {
+	require := suite.Require()
+
+	require.NoError(suite.store.Set(context.Background(), &model.User{
+		Name:           "Razieh Alvani",
+		Email:          raziehEmail,
+		Password:       "123456",
+		Admin:          false,
+		LegacyPassword: false,
+	}))
+
+	b, err := json.Marshal(request.Login{
+		Email:    raziehEmail,
+		Password: "123456",
+	})
+	require.NoError(err)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, "/login", bytes.NewReader(b))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+	suite.engine.ServeHTTP(w, req)
+	require.Equal(http.StatusOK, w.Code)
+
+	var login response.Login
+
+	require.NoError(json.Unmarshal(w.Body.Bytes(), &login))
+	require.NotEmpty(login.AccessToken)
+	require.NotEmpty(login.RefreshToken)
+
+	refreshToken := func(token string) (int, response.Token) {
+		b, err := json.Marshal(request.Refresh{RefreshToken: token})
+		require.NoError(err)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, "/token/refresh", bytes.NewReader(b))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		suite.engine.ServeHTTP(w, req)
+
+		var res response.Token
+
+		if w.Code == http.StatusOK {
+			require.NoError(json.Unmarshal(w.Body.Bytes(), &res))
+		}
+
+		return w.Code, res
+	}
+
+	code, rotated := refreshToken(login.RefreshToken)
+	require.Equal(http.StatusOK, code)
+	require.NotEmpty(rotated.AccessToken)
+	require.NotEqual(login.RefreshToken, rotated.RefreshToken)
+
+	// reusing the first refresh token revokes the rotated one too.
+	code, _ = refreshToken(login.RefreshToken)
+	require.Equal(http.StatusUnauthorized, code)
+
+	code, _ = refreshToken(rotated.RefreshToken)
+	require.Equal(http.StatusUnauthorized, code)
+
+	code, _ = refreshToken("invalid")
+	require.Equal(http.StatusUnauthorized, code)
 }
 
 func TestURLSuite(t *testing.T) {
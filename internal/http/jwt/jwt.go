@@ -2,10 +2,12 @@ package jwt
 
 import (
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/1995parham-teaching/fandogh/internal/http/common"
 	"github.com/1995parham-teaching/fandogh/internal/model"
+	"github.com/1995parham-teaching/fandogh/internal/store/denylist"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	echojwt "github.com/labstack/echo-jwt/v5"
@@ -19,38 +21,149 @@ type Claims struct {
 	Admin bool `json:"admin"`
 }
 
+// Config of the tokens. Without a SigningKey tokens are signed with HS256 using AccessTokenSecret,
+// otherwise they are signed by the key with SigningKey id and verified by any of the Keys.
 type Config struct {
-	AccessTokenSecret string
+	AccessTokenSecret string        `koanf:"access_secret"`
+	RefreshTokenTTL   time.Duration `koanf:"refresh_token_ttl"`
+	SigningKey        string        `koanf:"signing_key"`
+	Keys              []Key         `koanf:"keys"`
 }
 
+// AccessTokenTTL is the lifetime of access tokens, revocations are kept for the same duration.
+const AccessTokenTTL = time.Hour
+
 type JWT struct {
 	Config
+
+	Denylist denylist.Denylist
+
+	keys   map[string]key
+	signer *key
 }
 
 // Provide creates new JWT handler for dependency injection.
-func Provide(cfg Config) JWT {
-	return JWT{Config: cfg}
+func Provide(cfg Config, revocations denylist.Denylist) (JWT, error) {
+	j := JWT{
+		Config:   cfg,
+		Denylist: revocations,
+		keys:     make(map[string]key),
+		signer:   nil,
+	}
+
+	for _, k := range cfg.Keys {
+		loaded, err := loadKey(k)
+		if err != nil {
+			return j, fmt.Errorf("failed to load jwt key: %w", err)
+		}
+
+		if _, ok := j.keys[loaded.id]; ok {
+			return j, fmt.Errorf("duplicate jwt key id %s", loaded.id)
+		}
+
+		j.keys[loaded.id] = loaded
+	}
+
+	if cfg.SigningKey != "" {
+		signer, ok := j.keys[cfg.SigningKey]
+		if !ok || signer.private == nil {
+			return j, fmt.Errorf("signing key %s must be configured with its private key", cfg.SigningKey)
+		}
+
+		j.signer = &signer
+	}
+
+	return j, nil
 }
 
+// Middleware validates the access tokens and rejects the revoked ones.
 func (j JWT) Middleware() echo.MiddlewareFunc {
 	// nolint: exhaustruct
 	return echojwt.WithConfig(echojwt.Config{
-		ContextKey:    common.UserContextKey,
-		SigningKey:    []byte(j.AccessTokenSecret),
-		SigningMethod: jwt.SigningMethodHS256.Name,
+		ContextKey: common.UserContextKey,
+		KeyFunc:    j.keyFunc,
 		NewClaimsFunc: func(_ *echo.Context) jwt.Claims {
 			return new(Claims)
 		},
+		SuccessHandler: j.checkRevocation,
 	})
 }
 
+// keyFunc selects the verification key based on the token kid header. The signing method is checked
+// against the key, so a public key can never be used as an HMAC secret.
+func (j JWT) keyFunc(token *jwt.Token) (any, error) {
+	if j.signer == nil {
+		if token.Method != jwt.SigningMethodHS256 {
+			return nil, fmt.Errorf("unexpected jwt signing method %v", token.Header["alg"])
+		}
+
+		return []byte(j.AccessTokenSecret), nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+
+	k, ok := j.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: %v", ErrUnknownKey, token.Header["kid"])
+	}
+
+	if token.Method.Alg() != k.method.Alg() {
+		return nil, fmt.Errorf("unexpected jwt signing method %v for key %s", token.Header["alg"], kid)
+	}
+
+	return k.public, nil
+}
+
+// JWKS returns the public keys which verify the tokens, it is empty when tokens are signed with HS256
+// even when keys are configured, because keyFunc does not verify any token with them.
+func (j JWT) JWKS() JWKS {
+	set := JWKS{
+		Keys: make([]JWK, 0, len(j.keys)),
+	}
+
+	if j.signer == nil {
+		return set
+	}
+
+	for _, k := range j.Keys {
+		set.Keys = append(set.Keys, j.keys[k.ID].jwk())
+	}
+
+	return set
+}
+
+// checkRevocation rejects the access tokens which are revoked by logout or by an admin.
+// nolint: wrapcheck
+func (j JWT) checkRevocation(c *echo.Context) error {
+	token, ok := c.Get(common.UserContextKey).(*jwt.Token)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user claims not found")
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok || claims.IssuedAt == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid token claims")
+	}
+
+	revoked, err := j.Denylist.Revoked(c.Request().Context(), claims.ID, claims.Subject, claims.IssuedAt.Time)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if revoked {
+		return echo.NewHTTPError(http.StatusUnauthorized, "token is revoked")
+	}
+
+	return nil
+}
+
 // NewAccessToken creates new access token for given user.
 func (j JWT) NewAccessToken(u model.User) (string, error) {
 	// create token
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
 		RegisteredClaims: jwt.RegisteredClaims{
 			Audience:  jwt.ClaimStrings{"user"},
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(1 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenTTL)),
 			ID:        uuid.New().String(),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Issuer:    "fandogh",
@@ -60,8 +173,17 @@ func (j JWT) NewAccessToken(u model.User) (string, error) {
 		Admin: u.Admin,
 	})
 
+	var signingKey any = []byte(j.AccessTokenSecret)
+
+	if j.signer != nil {
+		token.Method = j.signer.method
+		token.Header["alg"] = j.signer.method.Alg()
+		token.Header["kid"] = j.signer.id
+		signingKey = j.signer.private
+	}
+
 	// generate encoded token and send it as response
-	encodedToken, err := token.SignedString([]byte(j.AccessTokenSecret))
+	encodedToken, err := token.SignedString(signingKey)
 	if err != nil {
 		return "", fmt.Errorf("failed to sign a token: %w", err)
 	}
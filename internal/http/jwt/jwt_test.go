@@ -0,0 +1,187 @@
+package jwt_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/1995parham-teaching/fandogh/internal/http/jwt"
+	"github.com/1995parham-teaching/fandogh/internal/model"
+	"github.com/1995parham-teaching/fandogh/internal/store/denylist"
+	"github.com/labstack/echo/v5"
+	"github.com/stretchr/testify/suite"
+)
+
+type JWTSuite struct {
+	suite.Suite
+
+	dir string
+}
+
+func (suite *JWTSuite) SetupSuite() {
+	require := suite.Require()
+
+	suite.dir = suite.T().TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(err)
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(err)
+
+	suite.write("rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+
+	b, err := x509.MarshalPKCS8PrivateKey(edKey)
+	require.NoError(err)
+	suite.write("ed25519.pem", "PRIVATE KEY", b)
+
+	b, err = x509.MarshalPKIXPublicKey(edKey.Public())
+	require.NoError(err)
+	suite.write("ed25519.pub.pem", "PUBLIC KEY", b)
+}
+
+func (suite *JWTSuite) write(name string, kind string, b []byte) {
+	suite.Require().NoError(os.WriteFile(
+		filepath.Join(suite.dir, name),
+		pem.EncodeToMemory(&pem.Block{Type: kind, Headers: nil, Bytes: b}),
+		0o600,
+	))
+}
+
+func (suite *JWTSuite) key(id string, private string, public string) jwt.Key {
+	k := jwt.Key{ID: id, PrivateKey: "", PublicKey: ""}
+
+	if private != "" {
+		k.PrivateKey = filepath.Join(suite.dir, private)
+	}
+
+	if public != "" {
+		k.PublicKey = filepath.Join(suite.dir, public)
+	}
+
+	return k
+}
+
+func (suite *JWTSuite) handler(signingKey string, keys ...jwt.Key) jwt.JWT {
+	j, err := jwt.Provide(jwt.Config{
+		AccessTokenSecret: "secret",
+		RefreshTokenTTL:   time.Hour,
+		SigningKey:        signingKey,
+		Keys:              keys,
+	}, denylist.NewMemoryDenylist())
+	suite.Require().NoError(err)
+
+	return j
+}
+
+func (suite *JWTSuite) token(j jwt.JWT) string {
+	token, err := j.NewAccessToken(model.User{
+		Email:          "parham.alvani@gmail.com",
+		Password:       "",
+		Name:           "",
+		Admin:          false,
+		LegacyPassword: false,
+	})
+	suite.Require().NoError(err)
+
+	return token
+}
+
+// verify returns the status code of a request with the given token behind the jwt middleware.
+func (suite *JWTSuite) verify(j jwt.JWT, token string) int {
+	e := echo.New()
+	e.GET("/", func(c *echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}, j.Middleware())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+
+	e.ServeHTTP(w, req)
+
+	return w.Code
+}
+
+func (suite *JWTSuite) TestSymmetric() {
+	require := suite.Require()
+
+	j := suite.handler("")
+
+	require.Equal(http.StatusNoContent, suite.verify(j, suite.token(j)))
+	require.Empty(j.JWKS().Keys)
+
+	// the keys are not published before one of them signs the tokens.
+	j = suite.handler("", suite.key("ed", "ed25519.pem", ""))
+
+	require.Equal(http.StatusNoContent, suite.verify(j, suite.token(j)))
+	require.Empty(j.JWKS().Keys)
+}
+
+func (suite *JWTSuite) TestRotation() {
+	require := suite.Require()
+
+	old := suite.handler("ed", suite.key("ed", "ed25519.pem", ""))
+	token := suite.token(old)
+
+	require.Equal(http.StatusNoContent, suite.verify(old, token))
+
+	// after the rotation, tokens of the old key are valid until they expire.
+	rotated := suite.handler("rsa", suite.key("rsa", "rsa.pem", ""), suite.key("ed", "", "ed25519.pub.pem"))
+
+	require.Equal(http.StatusNoContent, suite.verify(rotated, token))
+	require.Equal(http.StatusNoContent, suite.verify(rotated, suite.token(rotated)))
+
+	keys := rotated.JWKS().Keys
+	require.Len(keys, 2)
+	require.Equal("rsa", keys[0].KeyID)
+	require.Equal("RS256", keys[0].Algorithm)
+	require.Equal("ed", keys[1].KeyID)
+	require.Equal("EdDSA", keys[1].Algorithm)
+	require.Equal("Ed25519", keys[1].Curve)
+
+	// removing the key invalidates its tokens.
+	require.Equal(http.StatusUnauthorized, suite.verify(suite.handler("rsa", suite.key("rsa", "rsa.pem", "")), token))
+}
+
+func (suite *JWTSuite) TestSymmetricTokenRejected() {
+	require := suite.Require()
+
+	token := suite.token(suite.handler(""))
+
+	require.Equal(http.StatusUnauthorized, suite.verify(suite.handler("rsa", suite.key("rsa", "rsa.pem", "")), token))
+}
+
+func (suite *JWTSuite) TestInvalidSigningKey() {
+	require := suite.Require()
+
+	_, err := jwt.Provide(jwt.Config{
+		AccessTokenSecret: "",
+		RefreshTokenTTL:   time.Hour,
+		SigningKey:        "ed",
+		Keys:              []jwt.Key{suite.key("ed", "", "ed25519.pub.pem")},
+	}, denylist.NewMemoryDenylist())
+	require.Error(err)
+
+	_, err = jwt.Provide(jwt.Config{
+		AccessTokenSecret: "",
+		RefreshTokenTTL:   time.Hour,
+		SigningKey:        "",
+		Keys:              []jwt.Key{suite.key("ed", "ed25519.pub.pem", "")},
+	}, denylist.NewMemoryDenylist())
+	require.ErrorIs(err, jwt.ErrInvalidKey)
+}
+
+func TestJWTSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(JWTSuite))
+}
@@ -0,0 +1,194 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	// ErrInvalidKey indicates that the given key file does not contain an RSA or Ed25519 key in PEM format.
+	ErrInvalidKey = errors.New("key must be an RSA or Ed25519 key in PEM format")
+	// ErrUnknownKey indicates that the token is signed with a key which is not configured.
+	ErrUnknownKey = errors.New("unknown signing key")
+)
+
+// Key is an asymmetric key loaded from PEM files. A key with a private key can sign the tokens,
+// a key with only the public key is kept for verifying the tokens issued before a rotation.
+type Key struct {
+	ID         string `koanf:"id"`
+	PrivateKey string `koanf:"private_key"`
+	PublicKey  string `koanf:"public_key"`
+}
+
+// key is a loaded Key with its signing method, which is RS256 for RSA keys and EdDSA for Ed25519 keys.
+type key struct {
+	id      string
+	method  jwt.SigningMethod
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// JWK is a public key in JSON Web Key format.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+func loadKey(k Key) (key, error) {
+	if k.ID == "" {
+		return key{}, errors.New("key id is required")
+	}
+
+	result := key{
+		id:      k.ID,
+		method:  nil,
+		private: nil,
+		public:  nil,
+	}
+
+	switch {
+	case k.PrivateKey != "":
+		block, err := readPEM(k.PrivateKey)
+		if err != nil {
+			return result, err
+		}
+
+		private, err := parsePrivateKey(block)
+		if err != nil {
+			return result, fmt.Errorf("%s: %w", k.PrivateKey, err)
+		}
+
+		result.private = private
+		result.public = private.Public()
+	case k.PublicKey != "":
+		block, err := readPEM(k.PublicKey)
+		if err != nil {
+			return result, err
+		}
+
+		public, err := parsePublicKey(block)
+		if err != nil {
+			return result, fmt.Errorf("%s: %w", k.PublicKey, err)
+		}
+
+		result.public = public
+	default:
+		return result, fmt.Errorf("key %s has neither private nor public key", k.ID)
+	}
+
+	switch result.public.(type) {
+	case *rsa.PublicKey:
+		result.method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		result.method = jwt.SigningMethodEdDSA
+	default:
+		return result, ErrInvalidKey
+	}
+
+	return result, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read key file: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: %w", path, ErrInvalidKey)
+	}
+
+	return block, nil
+}
+
+func parsePrivateKey(block *pem.Block) (crypto.Signer, error) {
+	if block.Type == "RSA PRIVATE KEY" {
+		k, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidKey, err)
+		}
+
+		return k, nil
+	}
+
+	k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidKey, err)
+	}
+
+	switch k := k.(type) {
+	case *rsa.PrivateKey:
+		return k, nil
+	case ed25519.PrivateKey:
+		return k, nil
+	default:
+		return nil, ErrInvalidKey
+	}
+}
+
+func parsePublicKey(block *pem.Block) (crypto.PublicKey, error) {
+	if block.Type == "RSA PUBLIC KEY" {
+		k, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidKey, err)
+		}
+
+		return k, nil
+	}
+
+	k, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidKey, err)
+	}
+
+	return k, nil
+}
+
+// jwk converts the public key into JSON Web Key format.
+func (k key) jwk() JWK {
+	jwk := JWK{
+		KeyType:   "",
+		Use:       "sig",
+		Algorithm: k.method.Alg(),
+		KeyID:     k.id,
+		N:         "",
+		E:         "",
+		Curve:     "",
+		X:         "",
+	}
+
+	switch public := k.public.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	}
+
+	return jwk
+}
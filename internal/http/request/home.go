@@ -1,7 +1,11 @@
 package request
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 )
@@ -12,24 +16,38 @@ type PhotoInput struct {
 	Content string `json:"content"` // base64-encoded image data
 }
 
-// NewHome contains the home creation request payload.
+// Validate photo payload.
+func (p PhotoInput) Validate() error {
+	err := validation.ValidateStruct(&p,
+		validation.Field(&p.Name, validation.Required),
+		validation.Field(&p.Content, validation.Required),
+	)
+	if err != nil {
+		return fmt.Errorf("photo validation failed: %w", err)
+	}
+
+	return nil
+}
+
+// NewHome contains the home creation request payload. It is either a JSON body with base64 photos
+// or a multipart form which has the photos as its files.
 type NewHome struct {
-	Title           string       `json:"title"`
-	Location        string       `json:"location"`
-	Description     string       `json:"description"`
-	Peoples         int          `json:"peoples"`
-	Room            string       `json:"room"`
-	Bed             string       `json:"bed"`
-	Rooms           int          `json:"rooms"`
-	Bathrooms       int          `json:"bathrooms"`
-	Smoking         bool         `json:"smoking"`
-	Guest           bool         `json:"guest"`
-	Pet             bool         `json:"pet"`
-	BillsIncluded   bool         `json:"bills_included"`
-	Contract        string       `json:"contract"`
-	SecurityDeposit int          `json:"security_deposit"`
-	Price           int          `json:"price"`
-	Photos          []PhotoInput `json:"photos"`
+	Title           string       `form:"title"            json:"title"`
+	Location        string       `form:"location"         json:"location"`
+	Description     string       `form:"description"      json:"description"`
+	Peoples         int          `form:"peoples"          json:"peoples"`
+	Room            string       `form:"room"             json:"room"`
+	Bed             string       `form:"bed"              json:"bed"`
+	Rooms           int          `form:"rooms"            json:"rooms"`
+	Bathrooms       int          `form:"bathrooms"        json:"bathrooms"`
+	Smoking         bool         `form:"smoking"          json:"smoking"`
+	Guest           bool         `form:"guest"            json:"guest"`
+	Pet             bool         `form:"pet"              json:"pet"`
+	BillsIncluded   bool         `form:"bills_included"   json:"bills_included"`
+	Contract        string       `form:"contract"         json:"contract"`
+	SecurityDeposit int          `form:"security_deposit" json:"security_deposit"`
+	Price           int          `form:"price"            json:"price"`
+	Photos          []PhotoInput `form:"-"                json:"photos"`
 }
 
 // Validate home creation request payload.
@@ -96,3 +114,239 @@ func (r UpdateHome) Validate() error {
 
 	return nil
 }
+
+// ImageContentTypes are the content types of the photos which can be uploaded directly to the storage.
+// nolint: gochecknoglobals
+var ImageContentTypes = []any{"image/png", "image/jpeg", "image/gif", "image/webp"}
+
+// SortFields are the fields which homes can be sorted by, a "-" prefix sorts them in descending order.
+// nolint: gochecknoglobals
+var SortFields = []any{"price", "security_deposit", "rooms", "created_at"}
+
+// HomeFilter contains the home list filters, order and cursor, unset filters are nil.
+type HomeFilter struct {
+	Sort               string  `query:"sort"`
+	Cursor             string  `query:"cursor"`
+	MinPrice           *int    `query:"min_price"`
+	MaxPrice           *int    `query:"max_price"`
+	MinSecurityDeposit *int    `query:"min_security_deposit"`
+	MaxSecurityDeposit *int    `query:"max_security_deposit"`
+	MinRooms           *int    `query:"min_rooms"`
+	MinBathrooms       *int    `query:"min_bathrooms"`
+	MinPeoples         *int    `query:"min_peoples"`
+	Bed                *string `query:"bed"`
+	Location           *string `query:"location"`
+	Smoking            *bool   `query:"smoking"`
+	Guest              *bool   `query:"guest"`
+	Pet                *bool   `query:"pet"`
+	BillsIncluded      *bool   `query:"bills_included"`
+}
+
+// Validate home list filters.
+func (r HomeFilter) Validate() error {
+	err := validation.ValidateStruct(&r,
+		validation.Field(&r.MinPrice, validation.Min(0)),
+		validation.Field(&r.MaxPrice, validation.Min(0), validation.When(
+			r.MinPrice != nil, validation.Min(orZero(r.MinPrice)).Error("must be no less than min_price"),
+		)),
+		validation.Field(&r.MinSecurityDeposit, validation.Min(0)),
+		validation.Field(&r.MaxSecurityDeposit, validation.Min(0), validation.When(
+			r.MinSecurityDeposit != nil,
+			validation.Min(orZero(r.MinSecurityDeposit)).Error("must be no less than min_security_deposit"),
+		)),
+		validation.Field(&r.MinRooms, validation.Min(0)),
+		validation.Field(&r.MinBathrooms, validation.Min(0)),
+		validation.Field(&r.MinPeoples, validation.Min(0)),
+		validation.Field(&r.Bed, validation.In("single", "double")),
+		validation.Field(&r.Sort, validation.By(func(any) error {
+			field, _ := r.SortBy()
+
+			return validation.Validate(field, validation.In(SortFields...))
+		})),
+	)
+	if err != nil {
+		return fmt.Errorf("home filter validation failed: %w", err)
+	}
+
+	return nil
+}
+
+// SortBy returns the sort field and its direction, it is empty when there is no sort.
+func (r HomeFilter) SortBy() (string, bool) {
+	if field, ok := strings.CutPrefix(r.Sort, "-"); ok {
+		return field, true
+	}
+
+	return r.Sort, false
+}
+
+func orZero(v *int) int {
+	if v == nil {
+		return 0
+	}
+
+	return *v
+}
+
+// MIMEApplicationMergePatch is the content type of JSON merge patches (RFC 7396).
+const MIMEApplicationMergePatch = "application/merge-patch+json"
+
+// ErrNullPatch indicates that a merge patch removes a field, which is not possible because all home fields are required.
+var ErrNullPatch = errors.New("home fields cannot be removed")
+
+// PatchHome contains the home merge patch payload, absent fields are nil and remain unchanged.
+type PatchHome struct {
+	Title           *string `json:"title"`
+	Location        *string `json:"location"`
+	Description     *string `json:"description"`
+	Peoples         *int    `json:"peoples"`
+	Room            *string `json:"room"`
+	Bed             *string `json:"bed"`
+	Rooms           *int    `json:"rooms"`
+	Bathrooms       *int    `json:"bathrooms"`
+	Smoking         *bool   `json:"smoking"`
+	Guest           *bool   `json:"guest"`
+	Pet             *bool   `json:"pet"`
+	BillsIncluded   *bool   `json:"bills_included"`
+	Contract        *string `json:"contract"`
+	SecurityDeposit *int    `json:"security_deposit"`
+	Price           *int    `json:"price"`
+}
+
+// DecodePatchHome decodes a merge patch, null members and unknown fields are rejected.
+func DecodePatchHome(body []byte) (PatchHome, error) {
+	var (
+		patch   PatchHome
+		members map[string]json.RawMessage
+	)
+
+	if err := json.Unmarshal(body, &members); err != nil {
+		return patch, fmt.Errorf("merge patch must be a json object: %w", err)
+	}
+
+	for name, value := range members {
+		if string(value) == "null" {
+			return patch, fmt.Errorf("%w: %s", ErrNullPatch, name)
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&patch); err != nil {
+		return patch, fmt.Errorf("invalid merge patch: %w", err)
+	}
+
+	return patch, nil
+}
+
+// Validate home merge patch payload, only the given fields are validated.
+func (r PatchHome) Validate() error {
+	err := validation.ValidateStruct(&r,
+		validation.Field(&r.Title, validation.NilOrNotEmpty),
+		validation.Field(&r.Location, validation.NilOrNotEmpty),
+		validation.Field(&r.Description, validation.NilOrNotEmpty),
+		validation.Field(&r.Peoples, validation.NilOrNotEmpty),
+		validation.Field(&r.Room, validation.NilOrNotEmpty),
+		validation.Field(&r.Bed, validation.NilOrNotEmpty, validation.In("single", "double")),
+		validation.Field(&r.Rooms, validation.NilOrNotEmpty),
+		validation.Field(&r.Bathrooms, validation.NilOrNotEmpty),
+		validation.Field(&r.Contract, validation.NilOrNotEmpty),
+		validation.Field(&r.SecurityDeposit, validation.NilOrNotEmpty),
+		validation.Field(&r.Price, validation.NilOrNotEmpty),
+	)
+	if err != nil {
+		return fmt.Errorf("home patch request validation failed: %w", err)
+	}
+
+	return nil
+}
+
+// NewPhotos contains the photos which are added to an existing home.
+type NewPhotos struct {
+	Photos []PhotoInput `json:"photos"`
+}
+
+// Validate new photos request payload.
+func (r NewPhotos) Validate() error {
+	err := validation.ValidateStruct(&r,
+		validation.Field(&r.Photos, validation.Required),
+	)
+	if err != nil {
+		return fmt.Errorf("new photos request validation failed: %w", err)
+	}
+
+	return nil
+}
+
+// PhotoOrder contains the names of all the photos of a home in their new order.
+type PhotoOrder struct {
+	Order []string `json:"order"`
+}
+
+// Validate photo order request payload.
+func (r PhotoOrder) Validate() error {
+	err := validation.ValidateStruct(&r,
+		validation.Field(&r.Order, validation.Required, validation.Each(validation.Required)),
+	)
+	if err != nil {
+		return fmt.Errorf("photo order request validation failed: %w", err)
+	}
+
+	return nil
+}
+
+// Cover contains the name of the photo which becomes the cover of a home.
+type Cover struct {
+	Name string `json:"name"`
+}
+
+// Validate cover request payload.
+func (r Cover) Validate() error {
+	err := validation.ValidateStruct(&r,
+		validation.Field(&r.Name, validation.Required),
+	)
+	if err != nil {
+		return fmt.Errorf("cover request validation failed: %w", err)
+	}
+
+	return nil
+}
+
+// NewUpload contains the photo which the client uploads directly to the storage.
+type NewUpload struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+}
+
+// Validate new upload request payload, only images can be uploaded.
+func (r NewUpload) Validate() error {
+	err := validation.ValidateStruct(&r,
+		validation.Field(&r.Name, validation.Required),
+		validation.Field(&r.ContentType, validation.Required, validation.In(ImageContentTypes...)),
+	)
+	if err != nil {
+		return fmt.Errorf("new upload request validation failed: %w", err)
+	}
+
+	return nil
+}
+
+// ConfirmUpload contains the photo which is uploaded directly to the storage.
+type ConfirmUpload struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+}
+
+// Validate upload confirmation request payload.
+func (r ConfirmUpload) Validate() error {
+	err := validation.ValidateStruct(&r,
+		validation.Field(&r.Name, validation.Required),
+		validation.Field(&r.Key, validation.Required),
+	)
+	if err != nil {
+		return fmt.Errorf("upload confirmation request validation failed: %w", err)
+	}
+
+	return nil
+}
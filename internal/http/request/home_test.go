@@ -92,3 +92,198 @@ func TestNewHomeValidation(t *testing.T) {
 		}
 	}
 }
+
+func TestHomeFilterValidation(t *testing.T) {
+	t.Parallel()
+
+	value := func(v int) *int {
+		return &v
+	}
+
+	bed := "triple"
+
+	cases := []struct {
+		rq      request.HomeFilter
+		isValid bool
+	}{
+		{
+			// nolint: exhaustruct
+			rq:      request.HomeFilter{},
+			isValid: true,
+		},
+		{
+			// nolint: exhaustruct
+			rq: request.HomeFilter{
+				MinPrice: value(100),
+				MaxPrice: value(200),
+			},
+			isValid: true,
+		},
+		{
+			// nolint: exhaustruct
+			rq: request.HomeFilter{
+				MinPrice: value(200),
+				MaxPrice: value(100),
+			},
+			isValid: false,
+		},
+		{
+			// nolint: exhaustruct
+			rq: request.HomeFilter{
+				MinSecurityDeposit: value(200),
+				MaxSecurityDeposit: value(100),
+			},
+			isValid: false,
+		},
+		{
+			// nolint: exhaustruct
+			rq: request.HomeFilter{
+				MinRooms: value(-1),
+			},
+			isValid: false,
+		},
+		{
+			// nolint: exhaustruct
+			rq: request.HomeFilter{
+				Bed: &bed,
+			},
+			isValid: false,
+		},
+		{
+			// nolint: exhaustruct
+			rq: request.HomeFilter{
+				Sort: "-price",
+			},
+			isValid: true,
+		},
+		{
+			// nolint: exhaustruct
+			rq: request.HomeFilter{
+				Sort: "created_at",
+			},
+			isValid: true,
+		},
+		{
+			// nolint: exhaustruct
+			rq: request.HomeFilter{
+				Sort: "owner",
+			},
+			isValid: false,
+		},
+		{
+			// nolint: exhaustruct
+			rq: request.HomeFilter{
+				Sort: "--price",
+			},
+			isValid: false,
+		},
+	}
+
+	for _, c := range cases {
+		err := c.rq.Validate()
+		if c.isValid && err != nil {
+			t.Fatalf("valid request %+v has error %s", c.rq, err)
+		}
+
+		if !c.isValid && err == nil {
+			t.Fatalf("invalid request %+v has no error", c.rq)
+		}
+	}
+}
+
+func TestDecodePatchHome(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		body    string
+		isValid bool
+	}{
+		{body: `{ "price": 900 }`, isValid: true},
+		{body: `{ "bed": "double", "pet": false }`, isValid: true},
+		{body: `{}`, isValid: true},
+		{body: `{ "price": null }`, isValid: false},
+		{body: `{ "price": 0 }`, isValid: false},
+		{body: `{ "title": "" }`, isValid: false},
+		{body: `{ "bed": "triple" }`, isValid: false},
+		{body: `{ "owner": "elahe.dstn@gmail.com" }`, isValid: false},
+		{body: `[]`, isValid: false},
+	}
+
+	for _, c := range cases {
+		rq, err := request.DecodePatchHome([]byte(c.body))
+		if err == nil {
+			err = rq.Validate()
+		}
+
+		if c.isValid && err != nil {
+			t.Fatalf("valid patch %s has error %s", c.body, err)
+		}
+
+		if !c.isValid && err == nil {
+			t.Fatalf("invalid patch %s has no error", c.body)
+		}
+	}
+}
+
+func TestNewPhotosValidation(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		rq      request.NewPhotos
+		isValid bool
+	}{
+		{
+			rq:      request.NewPhotos{Photos: nil},
+			isValid: false,
+		},
+		{
+			rq:      request.NewPhotos{Photos: []request.PhotoInput{{Name: "1.png", Content: "MTIz"}}},
+			isValid: true,
+		},
+		{
+			rq:      request.NewPhotos{Photos: []request.PhotoInput{{Name: "", Content: "MTIz"}}},
+			isValid: false,
+		},
+		{
+			rq:      request.NewPhotos{Photos: []request.PhotoInput{{Name: "1.png", Content: ""}}},
+			isValid: false,
+		},
+	}
+
+	for _, c := range cases {
+		err := c.rq.Validate()
+		if c.isValid && err != nil {
+			t.Fatalf("valid request %+v has error %s", c.rq, err)
+		}
+
+		if !c.isValid && err == nil {
+			t.Fatalf("invalid request %+v has no error", c.rq)
+		}
+	}
+}
+
+func TestNewUploadValidation(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		rq      request.NewUpload
+		isValid bool
+	}{
+		{rq: request.NewUpload{Name: "1.png", ContentType: "image/png"}, isValid: true},
+		{rq: request.NewUpload{Name: "1.svg", ContentType: "image/svg+xml"}, isValid: false},
+		{rq: request.NewUpload{Name: "", ContentType: "image/png"}, isValid: false},
+		{rq: request.NewUpload{Name: "1.html", ContentType: "text/html"}, isValid: false},
+		{rq: request.NewUpload{Name: "1.png", ContentType: ""}, isValid: false},
+	}
+
+	for _, c := range cases {
+		err := c.rq.Validate()
+		if c.isValid && err != nil {
+			t.Fatalf("valid request %+v has error %s", c.rq, err)
+		}
+
+		if !c.isValid && err == nil {
+			t.Fatalf("invalid request %+v has no error", c.rq)
+		}
+	}
+}
@@ -33,6 +33,46 @@ func (r Register) Validate() error {
 	return nil
 }
 
+// Refresh represents a token refresh request payload.
+type Refresh struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// Validate token refresh request payload.
+func (r Refresh) Validate() error {
+	err := validation.ValidateStruct(&r,
+		validation.Field(&r.RefreshToken, validation.Required),
+	)
+	if err != nil {
+		return fmt.Errorf("refresh request validation failed: %w", err)
+	}
+
+	return nil
+}
+
+// Logout represents a logout request payload. The refresh token is optional and
+// when it is given, all the refresh tokens of its login are revoked too.
+type Logout struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// Revoke represents an admin request payload for revoking all tokens of a user.
+type Revoke struct {
+	Email string `json:"email"`
+}
+
+// Validate revoke request payload.
+func (r Revoke) Validate() error {
+	err := validation.ValidateStruct(&r,
+		validation.Field(&r.Email, validation.Required, is.Email),
+	)
+	if err != nil {
+		return fmt.Errorf("revoke request validation failed: %w", err)
+	}
+
+	return nil
+}
+
 // Login represents a login request payload.
 type Login struct {
 	Email    string `json:"email"`
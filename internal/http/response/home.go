@@ -0,0 +1,36 @@
+package response
+
+import (
+	"time"
+
+	"github.com/1995parham-teaching/fandogh/internal/model"
+)
+
+// Home contains a home with the presigned URLs of its photos by their names
+// and the presigned URLs of their thumbnails by their names and sizes.
+type Home struct {
+	model.Home
+
+	PhotoURLs     map[string]string            `json:"photo_urls"`
+	ThumbnailURLs map[string]map[string]string `json:"thumbnail_urls"`
+}
+
+// Homes contains a page of the homes with their photo URLs.
+type Homes struct {
+	Homes      []Home `json:"homes"`
+	Total      int64  `json:"total"`
+	Skip       int64  `json:"skip"`
+	Limit      int64  `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Upload contains the presigned URL for uploading a photo directly to the storage.
+// The photo must be uploaded with a PUT request with the same content type before the expiration
+// and then it must be confirmed with its name and key.
+type Upload struct {
+	Name        string    `json:"name"`
+	Key         string    `json:"key"`
+	URL         string    `json:"url"`
+	ContentType string    `json:"content_type"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
@@ -0,0 +1,14 @@
+package response
+
+// Readiness reports the status of each dependency, the server is ready when all of them are up.
+type Readiness struct {
+	Ready        bool                  `json:"ready"`
+	Dependencies map[string]Dependency `json:"dependencies"`
+}
+
+// Dependency reports whether a dependency is up with the duration of its check.
+type Dependency struct {
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
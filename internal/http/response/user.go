@@ -6,5 +6,12 @@ import "github.com/1995parham-teaching/fandogh/internal/model"
 type Login struct {
 	model.User
 
-	AccessToken string `json:"accessToken"`
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
+}
+
+// Token contains the renewed tokens from the token refresh endpoint.
+type Token struct {
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
 }
@@ -0,0 +1,27 @@
+package server
+
+import "time"
+
+// Config of the HTTP server, zero timeouts and sizes are not limited. MaxHeaderBytes is passed to http.Server
+// and MaxBodyBytes limits the body of each request.
+type Config struct {
+	Address           string        `koanf:"address"`
+	ReadTimeout       time.Duration `koanf:"read_timeout"`
+	ReadHeaderTimeout time.Duration `koanf:"read_header_timeout"`
+	WriteTimeout      time.Duration `koanf:"write_timeout"`
+	IdleTimeout       time.Duration `koanf:"idle_timeout"`
+	MaxHeaderBytes    int           `koanf:"max_header_bytes"`
+	MaxBodyBytes      int64         `koanf:"max_body_bytes"`
+	TLS               TLSConfig     `koanf:"tls"`
+}
+
+// TLSConfig enables TLS when the certificate and key files are given, they are reloaded when they change.
+type TLSConfig struct {
+	CertFile string `koanf:"cert_file"`
+	KeyFile  string `koanf:"key_file"`
+}
+
+// Enabled reports whether the server uses TLS.
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" || c.KeyFile != ""
+}
@@ -2,69 +2,191 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
+	"fmt"
 	"net/http"
+	"time"
 
+	"github.com/1995parham-teaching/fandogh/internal/fs"
 	"github.com/1995parham-teaching/fandogh/internal/http/handler"
 	"github.com/1995parham-teaching/fandogh/internal/http/jwt"
+	"github.com/1995parham-teaching/fandogh/internal/metric"
+	"github.com/1995parham-teaching/fandogh/internal/store/denylist"
 	"github.com/1995parham-teaching/fandogh/internal/store/home"
+	"github.com/1995parham-teaching/fandogh/internal/store/refresh"
 	"github.com/1995parham-teaching/fandogh/internal/store/user"
 	"github.com/labstack/echo/v5"
+	"github.com/labstack/echo/v5/middleware"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/readpref"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 )
 
+// readinessTimeout is the timeout of each readiness check.
+const readinessTimeout = 2 * time.Second
+
+// nolint: funlen
 func Provide(
 	lc fx.Lifecycle,
+	cfg Config,
+	database *mongo.Database,
 	userStore user.User,
 	homeStore home.Home,
+	refreshStore refresh.Refresh,
+	denylistStore denylist.Denylist,
+	storage fs.Storage,
 	logger *zap.Logger,
 	tracer trace.Tracer,
 	jwtHandler jwt.JWT,
-) *echo.Echo {
+	httpMetrics metric.HTTP,
+	userMetrics metric.User,
+	homeMetrics metric.Home,
+) (*echo.Echo, error) {
 	app := echo.New()
 
+	app.Use(httpMetrics.Middleware())
+
+	if cfg.MaxBodyBytes > 0 {
+		app.Use(middleware.BodyLimit(cfg.MaxBodyBytes))
+	}
+
 	handler.Healthz{
 		Logger: logger.Named("handler").Named("healthz"),
 		Tracer: tracer,
 	}.Register(app.Group(""))
 
-	handler.User{
-		Store:  userStore,
-		Tracer: tracer,
-		Logger: logger.Named("handler").Named("user"),
+	checks := map[string]handler.Check{
+		"storage": func(ctx context.Context) error {
+			if err := storage.Ping(ctx, home.Bucket); err != nil {
+				return fmt.Errorf("storage ping failed: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	// there is no database with the memory stores.
+	if database != nil {
+		checks["mongodb"] = func(ctx context.Context) error {
+			if err := database.Client().Ping(ctx, readpref.Primary()); err != nil {
+				return fmt.Errorf("mongodb ping failed: %w", err)
+			}
+
+			return nil
+		}
+	}
+
+	handler.Readyz{
+		Checks:  checks,
+		Timeout: readinessTimeout,
+		Logger:  logger.Named("handler").Named("readyz"),
+		Tracer:  tracer,
+	}.Register(app.Group(""))
+
+	handler.JWKS{
 		JWT:    jwtHandler,
+		Tracer: tracer,
 	}.Register(app.Group(""))
 
+	handler.User{
+		Store:   userStore,
+		Tokens:  refreshStore,
+		Tracer:  tracer,
+		Logger:  logger.Named("handler").Named("user"),
+		JWT:     jwtHandler,
+		Metrics: userMetrics,
+	}.Register(app.Group(""))
+
+	// presigned urls of the local storage are served by the server itself.
+	if files, ok := storage.(http.Handler); ok {
+		app.Match(
+			[]string{http.MethodGet, http.MethodHead, http.MethodPut},
+			"/files/*",
+			echo.WrapHandler(http.StripPrefix("/files", files)),
+		)
+	}
+
 	api := app.Group("/api", jwtHandler.Middleware())
 
 	handler.Home{
-		Store:  homeStore,
-		Tracer: tracer,
-		Logger: logger.Named("handler").Named("home"),
+		Store:   homeStore,
+		Storage: storage,
+		Tracer:  tracer,
+		Logger:  logger.Named("handler").Named("home"),
+		Metrics: homeMetrics,
+	}.Register(api)
+
+	handler.Session{
+		Denylist: denylistStore,
+		Tokens:   refreshStore,
+		Tracer:   tracer,
+		Logger:   logger.Named("handler").Named("session"),
 	}.Register(api)
 
 	// nolint: exhaustruct
 	server := &http.Server{
-		Addr:    ":1378",
-		Handler: app,
+		Addr:              cfg.Address,
+		Handler:           app,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+	}
+
+	var cert *Certificate
+
+	if cfg.TLS.Enabled() {
+		var err error
+
+		cert, err = NewCertificate(cfg.TLS, logger.Named("tls"))
+		if err != nil {
+			return nil, err
+		}
+
+		// nolint: exhaustruct
+		server.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: cert.GetCertificate,
+		}
 	}
 
 	lc.Append(
 		fx.Hook{
-			OnStart: func(_ context.Context) error {
+			OnStart: func(ctx context.Context) error {
+				// the photos bucket is created before the first upload, so the server becomes ready.
+				if err := storage.Bucket(ctx, home.Bucket); err != nil {
+					logger.Error("photos bucket creation failed", zap.Error(err))
+				}
+
 				go func() {
-					if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					logger.Info("starting http server", zap.String("address", server.Addr), zap.Bool("tls", cert != nil))
+
+					serve := server.ListenAndServe
+					if cert != nil {
+						// the certificate is given by the tls config.
+						serve = func() error { return server.ListenAndServeTLS("", "") }
+					}
+
+					if err := serve(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 						logger.Fatal("echo initiation failed", zap.Error(err))
 					}
 				}()
 
 				return nil
 			},
-			OnStop: server.Shutdown,
+			OnStop: func(ctx context.Context) error {
+				if cert != nil {
+					defer cert.Close()
+				}
+
+				return server.Shutdown(ctx)
+			},
 		},
 	)
 
-	return app
+	return app, nil
 }
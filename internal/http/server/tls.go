@@ -0,0 +1,122 @@
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+var ErrIncompleteTLS = errors.New("both of the tls certificate and key files are required")
+
+// Certificate keeps the TLS certificate of the server and reloads it when its files change,
+// so the renewed certificates are used without restarting the server.
+type Certificate struct {
+	certFile string
+	keyFile  string
+	current  atomic.Pointer[tls.Certificate]
+	watcher  *fsnotify.Watcher
+	logger   *zap.Logger
+}
+
+// NewCertificate loads the certificate and watches its files.
+func NewCertificate(cfg TLSConfig, logger *zap.Logger) (*Certificate, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, ErrIncompleteTLS
+	}
+
+	// nolint: exhaustruct
+	c := &Certificate{
+		certFile: filepath.Clean(cfg.CertFile),
+		keyFile:  filepath.Clean(cfg.KeyFile),
+		logger:   logger,
+	}
+
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("cannot watch the tls files: %w", err)
+	}
+
+	// the directories are watched, because the files are usually replaced instead of being written,
+	// e.g. kubernetes secrets are symlinks which are swapped on change.
+	for _, dir := range slices.Compact([]string{filepath.Dir(c.certFile), filepath.Dir(c.keyFile)}) {
+		if err := watcher.Add(dir); err != nil {
+			_ = watcher.Close()
+
+			return nil, fmt.Errorf("cannot watch the tls files in %s: %w", dir, err)
+		}
+	}
+
+	c.watcher = watcher
+
+	go c.watch()
+
+	return c, nil
+}
+
+func (c *Certificate) load() error {
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("cannot load the tls certificate: %w", err)
+	}
+
+	c.current.Store(&cert)
+
+	return nil
+}
+
+// watch reloads the certificate on the changes of its files. The previous certificate is kept when the files
+// cannot be loaded, e.g. when only one of them is replaced yet.
+func (c *Certificate) watch() {
+	for {
+		select {
+		case event, ok := <-c.watcher.Events:
+			if !ok {
+				return
+			}
+
+			name := filepath.Clean(event.Name)
+			if name != c.certFile && name != c.keyFile && !strings.HasPrefix(filepath.Base(name), "..") {
+				continue
+			}
+
+			if err := c.load(); err != nil {
+				c.logger.Warn("tls certificate reload failed, the previous one is kept", zap.Error(err))
+
+				continue
+			}
+
+			c.logger.Info("tls certificate is reloaded", zap.String("cert_file", c.certFile))
+		case err, ok := <-c.watcher.Errors:
+			if !ok {
+				return
+			}
+
+			c.logger.Error("tls files watch failed", zap.Error(err))
+		}
+	}
+}
+
+// GetCertificate returns the current certificate for tls.Config.
+func (c *Certificate) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return c.current.Load(), nil
+}
+
+// Close stops watching the files.
+func (c *Certificate) Close() error {
+	if err := c.watcher.Close(); err != nil {
+		return fmt.Errorf("cannot stop watching the tls files: %w", err)
+	}
+
+	return nil
+}
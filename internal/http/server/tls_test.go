@@ -0,0 +1,94 @@
+package server_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/1995parham-teaching/fandogh/internal/http/server"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// issue writes a self-signed certificate for the given name and its key into the given directory.
+func issue(t *testing.T, dir string, name string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	// nolint: exhaustruct
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	// the files are replaced like the certificate renewals.
+	for file, block := range map[string]*pem.Block{
+		"tls.crt": {Type: "CERTIFICATE", Bytes: der, Headers: nil},
+		"tls.key": {Type: "EC PRIVATE KEY", Bytes: keyDER, Headers: nil},
+	} {
+		tmp := filepath.Join(dir, "."+file)
+
+		require.NoError(t, os.WriteFile(tmp, pem.EncodeToMemory(block), 0o600))
+		require.NoError(t, os.Rename(tmp, filepath.Join(dir, file)))
+	}
+}
+
+func TestCertificateReload(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	issue(t, dir, "first.example.com")
+
+	cert, err := server.NewCertificate(server.TLSConfig{
+		CertFile: filepath.Join(dir, "tls.crt"),
+		KeyFile:  filepath.Join(dir, "tls.key"),
+	}, zap.NewNop())
+	require.NoError(t, err)
+
+	defer cert.Close()
+
+	name := func() string {
+		c, err := cert.GetCertificate(nil)
+		require.NoError(t, err)
+
+		leaf, err := x509.ParseCertificate(c.Certificate[0])
+		require.NoError(t, err)
+
+		return leaf.Subject.CommonName
+	}
+
+	require.Equal(t, "first.example.com", name())
+
+	issue(t, dir, "second.example.com")
+
+	require.Eventually(t, func() bool {
+		return name() == "second.example.com"
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestCertificateIncomplete(t *testing.T) {
+	t.Parallel()
+
+	_, err := server.NewCertificate(server.TLSConfig{CertFile: "tls.crt", KeyFile: ""}, zap.NewNop())
+	require.ErrorIs(t, err, server.ErrIncompleteTLS)
+}
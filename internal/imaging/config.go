@@ -0,0 +1,8 @@
+package imaging
+
+// Config of the photo processing, Thumbnails maps the thumbnail names to the maximum size of their width and height.
+type Config struct {
+	MaxWidth   int            `koanf:"max_width"`
+	MaxHeight  int            `koanf:"max_height"`
+	Thumbnails map[string]int `koanf:"thumbnails"`
+}
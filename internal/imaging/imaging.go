@@ -0,0 +1,159 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	// registers the GIF decoder, GIF photos are re-encoded as PNG.
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"golang.org/x/image/draw"
+	// registers the WebP decoder, WebP photos are re-encoded as PNG.
+	_ "golang.org/x/image/webp"
+)
+
+const jpegQuality = 85
+
+var (
+	ErrNotImage   = errors.New("photo must be a png, jpeg, gif or webp image")
+	ErrDimensions = errors.New("photo dimensions are larger than the limit")
+)
+
+// Encoded is an encoded image without any metadata.
+type Encoded struct {
+	Content     []byte
+	ContentType string
+}
+
+// Image is a processed photo with its thumbnails by their names.
+type Image struct {
+	Encoded
+
+	Thumbnails map[string]Encoded
+}
+
+// Processor validates the photos, re-encodes them to strip their metadata (e.g. EXIF and GPS) and creates
+// their thumbnails.
+type Processor struct {
+	cfg Config
+}
+
+// NewProcessor creates a processor and validates its configuration.
+func NewProcessor(cfg Config) (Processor, error) {
+	if cfg.MaxWidth <= 0 || cfg.MaxHeight <= 0 {
+		return Processor{}, errors.New("maximum photo width and height must be positive")
+	}
+
+	for name, size := range cfg.Thumbnails {
+		if name == "" || size <= 0 {
+			return Processor{}, fmt.Errorf("thumbnail %q must have a name and a positive size", name)
+		}
+	}
+
+	return Processor{cfg: cfg}, nil
+}
+
+// Provide creates a processor for dependency injection.
+func Provide(cfg Config) (Processor, error) {
+	return NewProcessor(cfg)
+}
+
+// Process decodes the given photo and re-encodes it with its thumbnails. The dimensions are checked
+// before decoding the photo, so large photos are rejected without allocating their pixels.
+// JPEG photos are rotated based on their EXIF orientation, because the orientation is removed with the metadata.
+// Only the first frame of the animated GIF photos is kept.
+func (p Processor) Process(r io.Reader) (Image, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Image{}, fmt.Errorf("cannot read photo: %w", err)
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, fmt.Errorf("%w: %w", ErrNotImage, err)
+	}
+
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return Image{}, ErrNotImage
+	}
+
+	if cfg.Width > p.cfg.MaxWidth || cfg.Height > p.cfg.MaxHeight {
+		return Image{}, fmt.Errorf("%w: %dx%d is not in %dx%d",
+			ErrDimensions, cfg.Width, cfg.Height, p.cfg.MaxWidth, p.cfg.MaxHeight)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, fmt.Errorf("%w: %w", ErrNotImage, err)
+	}
+
+	if format == "jpeg" {
+		img = orient(img, orientation(data))
+	}
+
+	original, err := encode(img, format)
+	if err != nil {
+		return Image{}, err
+	}
+
+	result := Image{
+		Encoded:    original,
+		Thumbnails: make(map[string]Encoded, len(p.cfg.Thumbnails)),
+	}
+
+	for name, size := range p.cfg.Thumbnails {
+		thumbnail, err := encode(resize(img, size), format)
+		if err != nil {
+			return Image{}, err
+		}
+
+		result.Thumbnails[name] = thumbnail
+	}
+
+	return result, nil
+}
+
+// encode encodes the JPEG images as JPEG and the others as PNG, so their transparency is kept.
+func encode(img image.Image, format string) (Encoded, error) {
+	var buf bytes.Buffer
+
+	if format == "jpeg" {
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return Encoded{}, fmt.Errorf("cannot encode jpeg: %w", err)
+		}
+
+		return Encoded{Content: buf.Bytes(), ContentType: "image/jpeg"}, nil
+	}
+
+	if err := png.Encode(&buf, img); err != nil {
+		return Encoded{}, fmt.Errorf("cannot encode png: %w", err)
+	}
+
+	return Encoded{Content: buf.Bytes(), ContentType: "image/png"}, nil
+}
+
+// resize scales the image down to fit in a square of the given size with the same aspect ratio.
+// Smaller images are not scaled up.
+func resize(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	if width <= size && height <= size {
+		return img
+	}
+
+	if width >= height {
+		width, height = size, max(1, height*size/width)
+	} else {
+		width, height = max(1, width*size/height), size
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+
+	return dst
+}
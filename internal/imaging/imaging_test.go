@@ -0,0 +1,144 @@
+package imaging_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/1995parham-teaching/fandogh/internal/imaging"
+	"github.com/stretchr/testify/require"
+)
+
+func processor(t *testing.T) imaging.Processor {
+	t.Helper()
+
+	p, err := imaging.NewProcessor(imaging.Config{
+		MaxWidth:  400,
+		MaxHeight: 400,
+		Thumbnails: map[string]int{
+			"small":  50,
+			"medium": 100,
+		},
+	})
+	require.NoError(t, err)
+
+	return p
+}
+
+func photo(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 0, A: 255})
+		}
+	}
+
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	require.NoError(t, png.Encode(&buf, img))
+
+	return buf.Bytes()
+}
+
+// encodeJPEG encodes the image as JPEG with an EXIF segment which contains the given orientation.
+func encodeJPEG(t *testing.T, img image.Image, orientation byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	require.NoError(t, jpeg.Encode(&buf, img, nil))
+
+	exif := []byte("Exif\x00\x00MM\x00\x2a\x00\x00\x00\x08\x00\x01\x01\x12\x00\x03\x00\x00\x00\x01\x00")
+	exif = append(exif, orientation, 0, 0, 0, 0, 0, 0)
+
+	segment := []byte{0xFF, 0xE1, 0, byte(len(exif) + 2)}
+	segment = append(segment, exif...)
+
+	data := buf.Bytes()
+
+	return append(append(data[:2:2], segment...), data[2:]...)
+}
+
+func TestProcessPNG(t *testing.T) {
+	t.Parallel()
+
+	img, err := processor(t).Process(bytes.NewReader(encodePNG(t, photo(200, 100))))
+	require.NoError(t, err)
+	require.Equal(t, "image/png", img.ContentType)
+
+	original, err := png.Decode(bytes.NewReader(img.Content))
+	require.NoError(t, err)
+	require.Equal(t, image.Rect(0, 0, 200, 100), original.Bounds())
+
+	require.Len(t, img.Thumbnails, 2)
+
+	small, err := png.Decode(bytes.NewReader(img.Thumbnails["small"].Content))
+	require.NoError(t, err)
+	require.Equal(t, image.Rect(0, 0, 50, 25), small.Bounds())
+
+	medium, err := png.Decode(bytes.NewReader(img.Thumbnails["medium"].Content))
+	require.NoError(t, err)
+	require.Equal(t, image.Rect(0, 0, 100, 50), medium.Bounds())
+}
+
+func TestProcessJPEG(t *testing.T) {
+	t.Parallel()
+
+	data := encodeJPEG(t, photo(80, 40), 6)
+	require.Contains(t, string(data), "Exif")
+
+	img, err := processor(t).Process(bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Equal(t, "image/jpeg", img.ContentType)
+	require.NotContains(t, string(img.Content), "Exif")
+
+	// the photo is rotated, so it is displayed correctly without its orientation.
+	original, err := jpeg.Decode(bytes.NewReader(img.Content))
+	require.NoError(t, err)
+	require.Equal(t, image.Rect(0, 0, 40, 80), original.Bounds())
+
+	small, err := jpeg.Decode(bytes.NewReader(img.Thumbnails["small"].Content))
+	require.NoError(t, err)
+	require.Equal(t, image.Rect(0, 0, 25, 50), small.Bounds())
+
+	// smaller photos are not scaled up.
+	medium, err := jpeg.Decode(bytes.NewReader(img.Thumbnails["medium"].Content))
+	require.NoError(t, err)
+	require.Equal(t, image.Rect(0, 0, 40, 80), medium.Bounds())
+}
+
+func TestProcessInvalid(t *testing.T) {
+	t.Parallel()
+
+	p := processor(t)
+
+	_, err := p.Process(strings.NewReader("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"))
+	require.ErrorIs(t, err, imaging.ErrNotImage)
+
+	_, err = p.Process(bytes.NewReader(encodePNG(t, photo(200, 100))[:50]))
+	require.ErrorIs(t, err, imaging.ErrNotImage)
+
+	_, err = p.Process(bytes.NewReader(encodePNG(t, photo(500, 10))))
+	require.ErrorIs(t, err, imaging.ErrDimensions)
+}
+
+func TestNewProcessor(t *testing.T) {
+	t.Parallel()
+
+	_, err := imaging.NewProcessor(imaging.Config{MaxWidth: 0, MaxHeight: 100, Thumbnails: nil})
+	require.Error(t, err)
+
+	_, err = imaging.NewProcessor(imaging.Config{MaxWidth: 100, MaxHeight: 100, Thumbnails: map[string]int{"small": 0}})
+	require.Error(t, err)
+}
@@ -0,0 +1,137 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+)
+
+// JPEG markers and the EXIF orientation tag which are used for finding the orientation.
+const (
+	markerAPP1     = 0xE1
+	markerSOS      = 0xDA
+	markerEOI      = 0xD9
+	orientationTag = 0x0112
+
+	// ifdEntrySize is the size of each entry in an EXIF image file directory.
+	ifdEntrySize = 12
+	// transposed is the first orientation which swaps the width and the height.
+	transposed = 5
+)
+
+// orientation returns the EXIF orientation of the given JPEG, which is 1 (normal) when it cannot be found.
+func orientation(data []byte) int {
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF || data[i+1] == markerSOS || data[i+1] == markerEOI {
+			return 1
+		}
+
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			return 1
+		}
+
+		segment := data[i+4 : i+2+length]
+		if data[i+1] == markerAPP1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+
+		i += 2 + length
+	}
+
+	return 1
+}
+
+// exifOrientation finds the orientation tag in the first image file directory of the given TIFF structure.
+func exifOrientation(tiff []byte) int {
+	const header = 8
+
+	if len(tiff) < header {
+		return 1
+	}
+
+	var order binary.ByteOrder
+
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	offset := int(order.Uint32(tiff[4:]))
+	if offset < header || offset+2 > len(tiff) {
+		return 1
+	}
+
+	count := int(order.Uint16(tiff[offset:]))
+
+	for i := range count {
+		entry := offset + 2 + i*ifdEntrySize
+		if entry+ifdEntrySize > len(tiff) {
+			return 1
+		}
+
+		if order.Uint16(tiff[entry:]) == orientationTag {
+			value := int(order.Uint16(tiff[entry+8:]))
+			if value < 1 || value > 8 {
+				return 1
+			}
+
+			return value
+		}
+	}
+
+	return 1
+}
+
+// orient transforms the image based on the given EXIF orientation, so it is displayed correctly without it.
+func orient(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	dw, dh := width, height
+	if orientation >= transposed {
+		dw, dh = height, width
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := range dh {
+		for x := range dw {
+			sx, sy := source(orientation, x, y, width, height)
+			dst.Set(x, y, img.At(bounds.Min.X+sx, bounds.Min.Y+sy))
+		}
+	}
+
+	return dst
+}
+
+// source returns the pixel of the source image which is shown at the given pixel based on the given orientation.
+// nolint: mnd
+func source(orientation, x, y, width, height int) (int, int) {
+	switch orientation {
+	case 2:
+		return width - 1 - x, y
+	case 3:
+		return width - 1 - x, height - 1 - y
+	case 4:
+		return x, height - 1 - y
+	case 5:
+		return y, x
+	case 6:
+		return y, height - 1 - x
+	case 7:
+		return width - 1 - y, height - 1 - x
+	case 8:
+		return width - 1 - y, x
+	default:
+		return x, y
+	}
+}
@@ -0,0 +1,93 @@
+package metric
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// User contains the metrics of the user registrations and logins, the logins are labelled by their result
+// which is failed for the wrong credentials.
+type User struct {
+	Registrations prometheus.Counter
+	Logins        *prometheus.CounterVec
+}
+
+// NewUser creates the user metrics and registers them on the given registry.
+func NewUser(reg prometheus.Registerer) (User, error) {
+	// nolint: exhaustruct
+	m := User{
+		Registrations: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "user",
+			Name:      "registrations_total",
+			Help:      "Number of the registered users.",
+		}),
+		Logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "user",
+			Name:      "logins_total",
+			Help:      "Number of the logins by their result.",
+		}, []string{"result"}),
+	}
+
+	// both of the results are exported before the first login.
+	m.Logins.WithLabelValues("succeeded")
+	m.Logins.WithLabelValues("failed")
+
+	if err := register(reg, m.Registrations, m.Logins); err != nil {
+		return m, err
+	}
+
+	return m, nil
+}
+
+// LoggedIn records a login with its result.
+func (m User) LoggedIn(succeeded bool) {
+	if succeeded {
+		m.Logins.WithLabelValues("succeeded").Inc()
+
+		return
+	}
+
+	m.Logins.WithLabelValues("failed").Inc()
+}
+
+// Home contains the metrics of the home changes and the size of the photos which are uploaded through the server.
+type Home struct {
+	Created       prometheus.Counter
+	Updated       prometheus.Counter
+	UploadedBytes prometheus.Counter
+}
+
+// NewHome creates the home metrics and registers them on the given registry.
+func NewHome(reg prometheus.Registerer) (Home, error) {
+	// nolint: exhaustruct
+	m := Home{
+		Created: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "home",
+			Name:      "created_total",
+			Help:      "Number of the created homes.",
+		}),
+		Updated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "home",
+			Name:      "updated_total",
+			Help:      "Number of the updated homes.",
+		}),
+		UploadedBytes: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "home",
+			Name:      "photo_uploaded_bytes_total",
+			Help:      "Size of the photos which are uploaded through the server.",
+		}),
+	}
+
+	if err := register(reg, m.Created, m.Updated, m.UploadedBytes); err != nil {
+		return m, err
+	}
+
+	return m, nil
+}
+
+// Uploaded records the size of the uploaded photos in bytes.
+func (m Home) Uploaded(size int64) {
+	m.UploadedBytes.Add(float64(size))
+}
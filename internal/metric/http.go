@@ -0,0 +1,104 @@
+package metric
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Namespace of the fandogh metrics.
+const Namespace = "fandogh"
+
+// unmatched is the route of the requests which do not match any route, so their paths do not increase
+// the cardinality of the metrics.
+const unmatched = "unmatched"
+
+// Registerer returns the registry which the metrics server exposes.
+func Registerer() prometheus.Registerer {
+	return prometheus.DefaultRegisterer
+}
+
+// register registers the given collectors on the registry.
+func register(reg prometheus.Registerer, collectors ...prometheus.Collector) error {
+	for _, c := range collectors {
+		if err := reg.Register(c); err != nil {
+			return fmt.Errorf("metric registration failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// HTTP contains the metrics of the HTTP requests by their method, route template and status.
+// The in-flight requests do not have a status yet, so they are only labelled by their method and route.
+type HTTP struct {
+	Requests *prometheus.CounterVec
+	Duration *prometheus.HistogramVec
+	InFlight *prometheus.GaugeVec
+}
+
+// NewHTTP creates the HTTP metrics and registers them on the given registry.
+func NewHTTP(reg prometheus.Registerer) (HTTP, error) {
+	// nolint: exhaustruct
+	m := HTTP{
+		Requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Number of the handled HTTP requests.",
+		}, []string{"method", "route", "status"}),
+		Duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of the handled HTTP requests.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		InFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Subsystem: "http",
+			Name:      "requests_in_flight",
+			Help:      "Number of the HTTP requests which are being handled.",
+		}, []string{"method", "route"}),
+	}
+
+	if err := register(reg, m.Requests, m.Duration, m.InFlight); err != nil {
+		return m, err
+	}
+
+	return m, nil
+}
+
+// Middleware records the metrics of each request, it must be used after routing so the route template is known.
+func (m HTTP) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c *echo.Context) error {
+			method := c.Request().Method
+
+			route := c.Path()
+			if route == "" {
+				route = unmatched
+			}
+
+			inFlight := m.InFlight.WithLabelValues(method, route)
+
+			inFlight.Inc()
+			defer inFlight.Dec()
+
+			start := time.Now()
+
+			err := next(c)
+
+			_, status := echo.ResolveResponseStatus(c.Response(), err)
+			code := strconv.Itoa(status)
+
+			m.Requests.WithLabelValues(method, route, code).Inc()
+			m.Duration.WithLabelValues(method, route, code).Observe(time.Since(start).Seconds())
+
+			return err
+		}
+	}
+}
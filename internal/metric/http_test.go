@@ -0,0 +1,56 @@
+package metric_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/1995parham-teaching/fandogh/internal/metric"
+	"github.com/labstack/echo/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPMiddleware(t *testing.T) {
+	t.Parallel()
+
+	m, err := metric.NewHTTP(prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	app := echo.New()
+	app.Use(m.Middleware())
+
+	app.GET("/homes/:id", func(c *echo.Context) error {
+		if c.Param("id") == "missing" {
+			return echo.NewHTTPError(http.StatusNotFound, "home does not exist")
+		}
+
+		// the request is in flight until the handler returns.
+		require.InDelta(t, 1, testutil.ToFloat64(m.InFlight.WithLabelValues(http.MethodGet, "/homes/:id")), 0)
+
+		return c.NoContent(http.StatusOK)
+	})
+
+	for _, path := range []string{"/homes/1", "/homes/2", "/homes/missing", "/unknown"} {
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, path, nil)
+		app.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// the requests are labelled by their route template, not their path.
+	require.InDelta(t, 2, testutil.ToFloat64(m.Requests.WithLabelValues(http.MethodGet, "/homes/:id", "200")), 0)
+	require.InDelta(t, 1, testutil.ToFloat64(m.Requests.WithLabelValues(http.MethodGet, "/homes/:id", "404")), 0)
+	require.InDelta(t, 1, testutil.ToFloat64(m.Requests.WithLabelValues(http.MethodGet, "unmatched", "404")), 0)
+	require.InDelta(t, 0, testutil.ToFloat64(m.InFlight.WithLabelValues(http.MethodGet, "/homes/:id")), 0)
+	require.Equal(t, 3, testutil.CollectAndCount(m.Duration))
+
+	// the second registration of the same metrics fails.
+	reg := prometheus.NewRegistry()
+
+	_, err = metric.NewHTTP(reg)
+	require.NoError(t, err)
+
+	_, err = metric.NewHTTP(reg)
+	require.Error(t, err)
+}
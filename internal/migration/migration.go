@@ -0,0 +1,257 @@
+package migration
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.uber.org/fx"
+)
+
+// Collection records the applied migrations by their versions.
+const Collection = "schema_migrations"
+
+// Ascending is the order of the index keys.
+const Ascending = 1
+
+var (
+	ErrDuplicateVersion = errors.New("migration version is registered more than once")
+	ErrIrreversible     = errors.New("migration cannot be reverted")
+	ErrInvalidSteps     = errors.New("number of the migrations to revert must be positive")
+)
+
+// Migration is a versioned change of the database. Versions order the migrations of all stores, so they are
+// the creation time of the migration (e.g. 202610180901). Down reverts Up and it is nil when the migration
+// cannot be reverted, e.g. for the data migrations which lose information.
+type Migration struct {
+	Version     int64
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+	Down        func(ctx context.Context, db *mongo.Database) error
+}
+
+// Status of a migration, AppliedAt is zero for the pending migrations.
+type Status struct {
+	Migration
+
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// record is an applied migration in the database.
+type record struct {
+	Version     int64     `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+}
+
+// Register adds the migrations which the given constructor returns, so each store registers its own migrations.
+func Register(constructor any) fx.Option {
+	return fx.Provide(
+		fx.Annotate(constructor, fx.ResultTags(`group:"migrations,flatten"`)),
+	)
+}
+
+// Migrator applies and reverts the migrations in the order of their versions.
+type Migrator struct {
+	db         *mongo.Database
+	migrations []Migration
+}
+
+// New creates a migrator for the given migrations, their versions must be unique.
+func New(db *mongo.Database, migrations []Migration) (*Migrator, error) {
+	migrations = slices.SortedFunc(slices.Values(migrations), func(a Migration, b Migration) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("%w: %d", ErrDuplicateVersion, migrations[i].Version)
+		}
+	}
+
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+	}, nil
+}
+
+// Params are the migrations which are registered by the stores.
+type Params struct {
+	fx.In
+
+	DB         *mongo.Database
+	Migrations []Migration `group:"migrations"`
+}
+
+// Provide creates a migrator for the registered migrations.
+func Provide(p Params) (*Migrator, error) {
+	return New(p.DB, p.Migrations)
+}
+
+// applied returns the applied migrations by their versions.
+func (m *Migrator) applied(ctx context.Context) (map[int64]record, error) {
+	cursor, err := m.db.Collection(Collection).Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("mongodb failed: %w", err)
+	}
+
+	var records []record
+
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("mongodb cursor decode failed: %w", err)
+	}
+
+	applied := make(map[int64]record, len(records))
+
+	for _, r := range records {
+		applied[r.Version] = r
+	}
+
+	return applied, nil
+}
+
+// Applied reports whether the migration of the given version is applied on the database, so the commands which
+// depend on a migration can refuse to run before it.
+func Applied(ctx context.Context, db *mongo.Database, version int64) (bool, error) {
+	n, err := db.Collection(Collection).CountDocuments(ctx, bson.M{"_id": version})
+	if err != nil {
+		return false, fmt.Errorf("mongodb failed: %w", err)
+	}
+
+	return n > 0, nil
+}
+
+// Status returns the status of each migration in the order of their versions.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+
+	for _, migration := range m.migrations {
+		r, ok := applied[migration.Version]
+
+		statuses = append(statuses, Status{
+			Migration: migration,
+			Applied:   ok,
+			AppliedAt: r.AppliedAt,
+		})
+	}
+
+	return statuses, nil
+}
+
+// Up applies the pending migrations in the order of their versions and returns the applied ones.
+// It stops on the first failed migration, so the next run continues from it.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	done := make([]Migration, 0)
+
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		if err := migration.Up(ctx, m.db); err != nil {
+			return done, fmt.Errorf("migration %d (%s) failed: %w", migration.Version, migration.Description, err)
+		}
+
+		if _, err := m.db.Collection(Collection).InsertOne(ctx, record{
+			Version:     migration.Version,
+			Description: migration.Description,
+			AppliedAt:   time.Now().UTC(),
+		}); err != nil {
+			return done, fmt.Errorf("migration %d cannot be recorded: %w", migration.Version, err)
+		}
+
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// Down reverts the given number of the last applied migrations and returns the reverted ones.
+// It stops on the first migration which cannot be reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if steps < 1 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidSteps, steps)
+	}
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	done := make([]Migration, 0, steps)
+
+	for _, migration := range slices.Backward(m.migrations) {
+		if len(done) == steps {
+			break
+		}
+
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+
+		if migration.Down == nil {
+			return done, fmt.Errorf("%w: %d (%s)", ErrIrreversible, migration.Version, migration.Description)
+		}
+
+		if err := migration.Down(ctx, m.db); err != nil {
+			return done, fmt.Errorf("migration %d (%s) revert failed: %w", migration.Version, migration.Description, err)
+		}
+
+		if _, err := m.db.Collection(Collection).DeleteOne(ctx, bson.M{"_id": migration.Version}); err != nil {
+			return done, fmt.Errorf("migration %d cannot be unrecorded: %w", migration.Version, err)
+		}
+
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// indexNotFound is the error code of dropping an index which does not exist.
+const indexNotFound = 27
+
+// Indexes returns a migration which creates the given indexes on the collection and drops them on revert.
+func Indexes(version int64, description string, collection string, indexes ...mongo.IndexModel) Migration {
+	return Migration{
+		Version:     version,
+		Description: description,
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			if _, err := db.Collection(collection).Indexes().CreateMany(ctx, indexes); err != nil {
+				return fmt.Errorf("index creation failed: %w", err)
+			}
+
+			return nil
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			for _, index := range indexes {
+				err := db.Collection(collection).Indexes().DropWithKey(ctx, index.Keys)
+
+				if cerr, ok := errors.AsType[mongo.CommandError](err); ok && cerr.Code == indexNotFound {
+					continue
+				}
+
+				if err != nil {
+					return fmt.Errorf("index deletion failed: %w", err)
+				}
+			}
+
+			return nil
+		},
+	}
+}
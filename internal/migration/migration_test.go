@@ -0,0 +1,138 @@
+package migration_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/1995parham-teaching/fandogh/internal/config"
+	"github.com/1995parham-teaching/fandogh/internal/db"
+	"github.com/1995parham-teaching/fandogh/internal/migration"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+)
+
+// collection is changed by the migrations of the tests.
+const collection = "migration_test"
+
+// insert returns a migration which inserts a document with its version and removes it on revert.
+func insert(version int64) migration.Migration {
+	return migration.Migration{
+		Version:     version,
+		Description: "insert",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection(collection).InsertOne(ctx, bson.M{"_id": version})
+
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection(collection).DeleteOne(ctx, bson.M{"_id": version})
+
+			return err
+		},
+	}
+}
+
+func TestDuplicateVersion(t *testing.T) {
+	t.Parallel()
+
+	_, err := migration.New(nil, []migration.Migration{insert(1), insert(2), insert(1)})
+	require.ErrorIs(t, err, migration.ErrDuplicateVersion)
+}
+
+func TestInvalidSteps(t *testing.T) {
+	t.Parallel()
+
+	migrator, err := migration.New(nil, []migration.Migration{insert(1)})
+	require.NoError(t, err)
+
+	for _, steps := range []int{0, -1} {
+		_, err := migrator.Down(context.Background(), steps)
+		require.ErrorIs(t, err, migration.ErrInvalidSteps)
+	}
+}
+
+type MigrationSuite struct {
+	suite.Suite
+
+	DB  *mongo.Database
+	app *fxtest.App
+}
+
+func (suite *MigrationSuite) SetupSuite() {
+	var database *mongo.Database
+
+	suite.app = fxtest.New(
+		suite.T(),
+		fx.Provide(config.Provide),
+		fx.Provide(zap.NewNop),
+		fx.Provide(db.Provide),
+		fx.Populate(&database),
+	)
+	suite.app.RequireStart()
+
+	suite.DB = database.Client().Database(database.Name() + "_migration_test")
+}
+
+func (suite *MigrationSuite) TearDownSuite() {
+	suite.Require().NoError(suite.DB.Drop(context.Background()))
+
+	suite.app.RequireStop()
+}
+
+func (suite *MigrationSuite) count() int64 {
+	n, err := suite.DB.Collection(collection).CountDocuments(context.Background(), bson.M{})
+	suite.Require().NoError(err)
+
+	return n
+}
+
+func (suite *MigrationSuite) TestUpDown() {
+	require := suite.Require()
+	ctx := context.Background()
+
+	irreversible := insert(1)
+	irreversible.Down = nil
+
+	migrator, err := migration.New(suite.DB, []migration.Migration{insert(3), irreversible, insert(2)})
+	require.NoError(err)
+
+	applied, err := migrator.Up(ctx)
+	require.NoError(err)
+	require.Len(applied, 3)
+	require.Equal([]int64{1, 2, 3}, []int64{applied[0].Version, applied[1].Version, applied[2].Version})
+	require.Equal(int64(3), suite.count())
+
+	// applied migrations are not applied again.
+	applied, err = migrator.Up(ctx)
+	require.NoError(err)
+	require.Empty(applied)
+
+	reverted, err := migrator.Down(ctx, 1)
+	require.NoError(err)
+	require.Len(reverted, 1)
+	require.Equal(int64(3), reverted[0].Version)
+	require.Equal(int64(2), suite.count())
+
+	statuses, err := migrator.Status(ctx)
+	require.NoError(err)
+	require.Len(statuses, 3)
+	require.True(statuses[0].Applied)
+	require.True(statuses[1].Applied)
+	require.False(statuses[2].Applied)
+	require.True(statuses[2].AppliedAt.IsZero())
+
+	reverted, err = migrator.Down(ctx, 2)
+	require.ErrorIs(err, migration.ErrIrreversible)
+	require.Len(reverted, 1)
+	require.Equal(int64(1), suite.count())
+}
+
+func TestMigrationSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(MigrationSuite))
+}
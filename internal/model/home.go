@@ -1,5 +1,7 @@
 package model
 
+import "io"
+
 type Bed int
 
 const (
@@ -7,31 +9,41 @@ const (
 	Double Bed = 2
 )
 
-// Photo contains the information for S3-compatible storage.
+// Photo contains the information for S3-compatible storage. Content is streamed to the storage,
+// so Size must be its length in bytes.
 type Photo struct {
 	Name        string
 	ContentType string
-	Content     []byte
+	Content     io.Reader
+	Size        int64
 }
 
 // Home represents a home to rent. contract types and room types are string to handle them more easier.
+// Photos maps the photo names to their S3 keys and Thumbnails maps them to the S3 keys of their thumbnails by size.
+// PhotoOrder contains the same names in their display order and Cover is the name of the cover photo,
+// which is empty only when there is no photo.
+// Version increases on each change, so concurrent changes based on the same version cannot both succeed.
 type Home struct {
-	ID              string            `bson:"_id"`
-	Owner           string            `bson:"owner"`
-	Title           string            `bson:"title"`
-	Location        string            `bson:"location"`
-	Description     string            `bson:"description"`
-	Peoples         int               `bson:"peoples"`
-	Room            string            `bson:"room"`
-	Bed             Bed               `bson:"bed"`
-	Rooms           int               `bson:"rooms"`
-	Bathrooms       int               `bson:"bathrooms"`
-	Smoking         bool              `bson:"smoking"`
-	Guest           bool              `bson:"guest"`
-	Pet             bool              `bson:"pet"`
-	BillsIncluded   bool              `bson:"bills_included"`
-	Contract        string            `bson:"contract"`
-	SecurityDeposit int               `bson:"security_deposit"`
-	Photos          map[string]string `bson:"photos"`
-	Price           int               `bson:"price"`
+	ID              string                       `bson:"_id"`
+	Owner           string                       `bson:"owner"`
+	Title           string                       `bson:"title"`
+	Location        string                       `bson:"location"`
+	Description     string                       `bson:"description"`
+	Peoples         int                          `bson:"peoples"`
+	Room            string                       `bson:"room"`
+	Bed             Bed                          `bson:"bed"`
+	Rooms           int                          `bson:"rooms"`
+	Bathrooms       int                          `bson:"bathrooms"`
+	Smoking         bool                         `bson:"smoking"`
+	Guest           bool                         `bson:"guest"`
+	Pet             bool                         `bson:"pet"`
+	BillsIncluded   bool                         `bson:"bills_included"`
+	Contract        string                       `bson:"contract"`
+	SecurityDeposit int                          `bson:"security_deposit"`
+	Photos          map[string]string            `bson:"photos"`
+	Thumbnails      map[string]map[string]string `bson:"thumbnails"`
+	PhotoOrder      []string                     `bson:"photo_order"`
+	Cover           string                       `bson:"cover"`
+	Price           int                          `bson:"price"`
+	Version         int64                        `bson:"version"`
 }
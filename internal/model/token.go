@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// RefreshToken is an opaque token for renewing access tokens. Only the hash of the token is stored as its ID.
+// Each refresh rotates the token and the new one joins the same family, so reusing an already rotated token
+// reveals a leak and revokes the whole family.
+type RefreshToken struct {
+	ID        string    `bson:"_id"`
+	Family    string    `bson:"family"`
+	Email     string    `bson:"email"`
+	Rotated   bool      `bson:"rotated"`
+	Revoked   bool      `bson:"revoked"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// Revocation marks access tokens as revoked until they expire. It revokes a single token by its id (jti) or,
+// when Subject is set, every token of that user which is issued up to RevokedAt.
+type Revocation struct {
+	ID        string    `bson:"_id"`
+	Subject   string    `bson:"subject,omitempty"`
+	RevokedAt time.Time `bson:"revoked_at"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
@@ -1,8 +1,11 @@
 package model
 
+// User represents a registered user. Password holds the password hash, LegacyPassword marks
+// records that still contain a plaintext password from before hashing was introduced.
 type User struct {
-	Email    string `bson:"email"`
-	Password string `bson:"password"`
-	Name     string `bson:"name"`
-	Admin    bool   `bson:"admin"`
+	Email          string `bson:"email"`
+	Password       string `bson:"password"                  json:"-"`
+	Name           string `bson:"name"`
+	Admin          bool   `bson:"admin"`
+	LegacyPassword bool   `bson:"legacy_password,omitempty" json:"-"`
 }
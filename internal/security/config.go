@@ -0,0 +1,23 @@
+package security
+
+// Config contains the security configuration.
+type Config struct {
+	Password Password `koanf:"password"`
+}
+
+// Password configures how user passwords are hashed. Algorithm is either argon2id or bcrypt,
+// changing it (or its parameters) causes the stored hashes to be upgraded on the next login.
+type Password struct {
+	Algorithm  string `koanf:"algorithm"`
+	BcryptCost int    `koanf:"bcrypt_cost"`
+	Argon2     Argon2 `koanf:"argon2"`
+}
+
+// Argon2 contains argon2id parameters, memory is in KiB.
+type Argon2 struct {
+	Time       uint32 `koanf:"time"`
+	Memory     uint32 `koanf:"memory"`
+	Threads    uint8  `koanf:"threads"`
+	KeyLength  uint32 `koanf:"key_length"`
+	SaltLength uint32 `koanf:"salt_length"`
+}
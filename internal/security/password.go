@@ -0,0 +1,188 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// Argon2ID hashes passwords with argon2id and stores them in the PHC string format.
+	Argon2ID = "argon2id"
+	// Bcrypt hashes passwords with bcrypt.
+	Bcrypt = "bcrypt"
+)
+
+var (
+	// ErrPasswordMismatch indicates that given password does not match the stored hash.
+	ErrPasswordMismatch = errors.New("password does not match")
+	// ErrUnknownHash indicates that the stored hash is not produced by any supported algorithm.
+	ErrUnknownHash = errors.New("unknown password hash format")
+	// ErrUnknownAlgorithm indicates that the configured algorithm is not supported.
+	ErrUnknownAlgorithm = errors.New("unknown password hashing algorithm")
+)
+
+// Hasher hashes and verifies user passwords based on the configured algorithm.
+// Verification detects the algorithm from the stored hash, so hashes created with
+// a previous configuration are still accepted and can be upgraded with NeedsRehash.
+type Hasher struct {
+	cfg Password
+}
+
+// NewHasher creates a password hasher for the given configuration.
+func NewHasher(cfg Password) (Hasher, error) {
+	switch cfg.Algorithm {
+	case Argon2ID, Bcrypt:
+	default:
+		return Hasher{}, fmt.Errorf("%w: %s", ErrUnknownAlgorithm, cfg.Algorithm)
+	}
+
+	if cfg.Algorithm == Bcrypt && (cfg.BcryptCost < bcrypt.MinCost || cfg.BcryptCost > bcrypt.MaxCost) {
+		return Hasher{}, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	if cfg.Algorithm == Argon2ID {
+		p := cfg.Argon2
+		if p.Time == 0 || p.Memory == 0 || p.Threads == 0 || p.KeyLength == 0 || p.SaltLength == 0 {
+			return Hasher{}, errors.New("argon2 parameters must be positive")
+		}
+	}
+
+	return Hasher{cfg: cfg}, nil
+}
+
+// Provide creates password hasher for dependency injection.
+func Provide(cfg Config) (Hasher, error) {
+	return NewHasher(cfg.Password)
+}
+
+// Hash hashes the given password with the configured algorithm.
+func (h Hasher) Hash(password string) (string, error) {
+	if h.cfg.Algorithm == Bcrypt {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cfg.BcryptCost)
+		if err != nil {
+			return "", fmt.Errorf("bcrypt failed: %w", err)
+		}
+
+		return string(hash), nil
+	}
+
+	salt := make([]byte, h.cfg.Argon2.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("salt generation failed: %w", err)
+	}
+
+	p := h.cfg.Argon2
+	key := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, p.KeyLength)
+
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.Memory, p.Time, p.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Compare checks the given password against the stored hash.
+func (h Hasher) Compare(hash, password string) error {
+	switch {
+	case isBcrypt(hash):
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrPasswordMismatch
+		}
+
+		if err != nil {
+			return fmt.Errorf("bcrypt failed: %w", err)
+		}
+
+		return nil
+	case strings.HasPrefix(hash, "$argon2id$"):
+		p, salt, key, err := decodeArgon2(hash)
+		if err != nil {
+			return err
+		}
+
+		// nolint: gosec
+		other := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, uint32(len(key)))
+		if subtle.ConstantTimeCompare(key, other) != 1 {
+			return ErrPasswordMismatch
+		}
+
+		return nil
+	default:
+		return ErrUnknownHash
+	}
+}
+
+// NeedsRehash reports whether the stored hash was created with a different algorithm or parameters
+// than the current configuration.
+func (h Hasher) NeedsRehash(hash string) bool {
+	if h.cfg.Algorithm == Bcrypt {
+		if !isBcrypt(hash) {
+			return true
+		}
+
+		cost, err := bcrypt.Cost([]byte(hash))
+
+		return err != nil || cost != h.cfg.BcryptCost
+	}
+
+	p, salt, key, err := decodeArgon2(hash)
+	if err != nil {
+		return true
+	}
+
+	// nolint: gosec
+	return p.Time != h.cfg.Argon2.Time || p.Memory != h.cfg.Argon2.Memory || p.Threads != h.cfg.Argon2.Threads ||
+		uint32(len(key)) != h.cfg.Argon2.KeyLength || uint32(len(salt)) != h.cfg.Argon2.SaltLength
+}
+
+// IsHash reports whether the given value looks like a hash produced by a supported algorithm.
+// Other values are considered plaintext passwords from before hashing was introduced.
+func IsHash(value string) bool {
+	return isBcrypt(value) || strings.HasPrefix(value, "$argon2id$")
+}
+
+func isBcrypt(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+// decodeArgon2 parses $argon2id$v=19$m=<memory>,t=<time>,p=<threads>$<salt>$<key>.
+func decodeArgon2(hash string) (Argon2, []byte, []byte, error) {
+	const fields = 6
+
+	var p Argon2
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != fields || parts[1] != Argon2ID {
+		return p, nil, nil, ErrUnknownHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, ErrUnknownHash
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Time, &p.Threads); err != nil {
+		return p, nil, nil, ErrUnknownHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, ErrUnknownHash
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return p, nil, nil, ErrUnknownHash
+	}
+
+	return p, salt, key, nil
+}
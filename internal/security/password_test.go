@@ -0,0 +1,93 @@
+package security_test
+
+import (
+	"testing"
+
+	"github.com/1995parham-teaching/fandogh/internal/security"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func argon2Config() security.Password {
+	return security.Password{
+		Algorithm:  security.Argon2ID,
+		BcryptCost: 0,
+		Argon2: security.Argon2{
+			Time:       1,
+			Memory:     8 * 1024,
+			Threads:    1,
+			KeyLength:  32,
+			SaltLength: 16,
+		},
+	}
+}
+
+func bcryptConfig() security.Password {
+	cfg := argon2Config()
+	cfg.Algorithm = security.Bcrypt
+	cfg.BcryptCost = bcrypt.MinCost
+
+	return cfg
+}
+
+func TestHashCompare(t *testing.T) {
+	t.Parallel()
+
+	for _, cfg := range []security.Password{argon2Config(), bcryptConfig()} {
+		h, err := security.NewHasher(cfg)
+		require.NoError(t, err)
+
+		hash, err := h.Hash("123456")
+		require.NoError(t, err)
+		require.NotEqual(t, "123456", hash)
+		require.True(t, security.IsHash(hash))
+
+		require.NoError(t, h.Compare(hash, "123456"))
+		require.ErrorIs(t, h.Compare(hash, "1234567"), security.ErrPasswordMismatch)
+		require.False(t, h.NeedsRehash(hash))
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	t.Parallel()
+
+	argon, err := security.NewHasher(argon2Config())
+	require.NoError(t, err)
+
+	cfg := argon2Config()
+	cfg.Argon2.Time = 2
+
+	stronger, err := security.NewHasher(cfg)
+	require.NoError(t, err)
+
+	bc, err := security.NewHasher(bcryptConfig())
+	require.NoError(t, err)
+
+	hash, err := argon.Hash("123456")
+	require.NoError(t, err)
+
+	require.True(t, stronger.NeedsRehash(hash))
+	require.True(t, bc.NeedsRehash(hash))
+
+	// hashes of the previous configuration are still accepted.
+	require.NoError(t, stronger.Compare(hash, "123456"))
+	require.NoError(t, bc.Compare(hash, "123456"))
+}
+
+func TestUnknownHash(t *testing.T) {
+	t.Parallel()
+
+	h, err := security.NewHasher(argon2Config())
+	require.NoError(t, err)
+
+	require.False(t, security.IsHash("123456"))
+	require.ErrorIs(t, h.Compare("123456", "123456"), security.ErrUnknownHash)
+	require.True(t, h.NeedsRehash("123456"))
+
+	_, err = security.NewHasher(security.Password{
+		Algorithm:  "md5",
+		BcryptCost: 0,
+		Argon2:     security.Argon2{Time: 0, Memory: 0, Threads: 0, KeyLength: 0, SaltLength: 0},
+	})
+	require.ErrorIs(t, err, security.ErrUnknownAlgorithm)
+}
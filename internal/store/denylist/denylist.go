@@ -0,0 +1,42 @@
+package denylist
+
+import (
+	"context"
+	"time"
+
+	"github.com/1995parham-teaching/fandogh/internal/model"
+)
+
+// subjectPrefix distinguishes the revocations of a user from the revocations of a single token.
+const subjectPrefix = "sub:"
+
+// Denylist stores revoked access tokens until they expire.
+type Denylist interface {
+	// Revoke revokes the access token with the given id (jti).
+	Revoke(ctx context.Context, id string, expiresAt time.Time) error
+	// RevokeSubject revokes every access token of the given user which is issued before the current second.
+	RevokeSubject(ctx context.Context, subject string, expiresAt time.Time) error
+	// Revoked reports whether the access token with the given id, subject and issue time is revoked.
+	Revoked(ctx context.Context, id string, subject string, issuedAt time.Time) (bool, error)
+}
+
+// revoked checks the given token against its revocations.
+func revoked(revocations []model.Revocation, id string, issuedAt time.Time) bool {
+	for _, r := range revocations {
+		if r.ExpiresAt.Before(time.Now()) {
+			continue
+		}
+
+		if r.ID == id {
+			return true
+		}
+
+		// token issue time has the second precision, so the tokens which are issued in the second of the revocation
+		// are kept, otherwise the tokens of a login right after the revocation would be revoked too.
+		if r.Subject != "" && issuedAt.Before(r.RevokedAt.Truncate(time.Second)) {
+			return true
+		}
+	}
+
+	return false
+}
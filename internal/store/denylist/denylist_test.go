@@ -0,0 +1,168 @@
+package denylist_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+
+	"github.com/1995parham-teaching/fandogh/internal/config"
+	"github.com/1995parham-teaching/fandogh/internal/db"
+	"github.com/1995parham-teaching/fandogh/internal/store/denylist"
+)
+
+const email = "parham.alvani@gmail.com"
+
+type CommonDenylistSuite struct {
+	suite.Suite
+
+	Store denylist.Denylist
+}
+
+func (suite *CommonDenylistSuite) TestRevoke() {
+	require := suite.Require()
+
+	issuedAt := time.Now().Add(-time.Minute)
+
+	revoked, err := suite.Store.Revoked(context.Background(), "1378", email, issuedAt)
+	require.NoError(err)
+	require.False(revoked)
+
+	require.NoError(suite.Store.Revoke(context.Background(), "1378", time.Now().Add(time.Hour)))
+
+	revoked, err = suite.Store.Revoked(context.Background(), "1378", email, issuedAt)
+	require.NoError(err)
+	require.True(revoked)
+
+	revoked, err = suite.Store.Revoked(context.Background(), "1379", email, issuedAt)
+	require.NoError(err)
+	require.False(revoked)
+}
+
+func (suite *CommonDenylistSuite) TestRevokeSubject() {
+	require := suite.Require()
+
+	const subject = "elahe.dstn@gmail.com"
+
+	require.NoError(suite.Store.RevokeSubject(context.Background(), subject, time.Now().Add(time.Hour)))
+
+	revoked, err := suite.Store.Revoked(context.Background(), "1", subject, time.Now().Add(-time.Minute))
+	require.NoError(err)
+	require.True(revoked)
+
+	// tokens issued after the revocation are valid.
+	revoked, err = suite.Store.Revoked(context.Background(), "2", subject, time.Now().Add(time.Minute))
+	require.NoError(err)
+	require.False(revoked)
+}
+
+// TestRevokeSubjectSameSecond issues the tokens with the second precision of the jwt issue time.
+func (suite *CommonDenylistSuite) TestRevokeSubjectSameSecond() {
+	require := suite.Require()
+
+	const subject = "razieh.alvani@gmail.com"
+
+	before := time.Now().Truncate(time.Second).Add(-time.Second)
+
+	require.NoError(suite.Store.RevokeSubject(context.Background(), subject, time.Now().Add(time.Hour)))
+
+	after := time.Now().Truncate(time.Second)
+
+	revoked, err := suite.Store.Revoked(context.Background(), "1", subject, before)
+	require.NoError(err)
+	require.True(revoked)
+
+	// a login right after the revocation.
+	revoked, err = suite.Store.Revoked(context.Background(), "2", subject, after)
+	require.NoError(err)
+	require.False(revoked)
+}
+
+func (suite *CommonDenylistSuite) TestExpired() {
+	require := suite.Require()
+
+	require.NoError(suite.Store.Revoke(context.Background(), "2020", time.Now().Add(-time.Minute)))
+
+	revoked, err := suite.Store.Revoked(context.Background(), "2020", email, time.Now().Add(-time.Hour))
+	require.NoError(err)
+	require.False(revoked)
+}
+
+type MongoDenylistSuite struct {
+	CommonDenylistSuite
+
+	DB  *mongo.Database
+	app *fxtest.App
+}
+
+func (suite *MongoDenylistSuite) SetupSuite() {
+	var (
+		database      *mongo.Database
+		denylistStore denylist.Denylist
+	)
+
+	suite.app = fxtest.New(
+		suite.T(),
+		fx.Provide(config.Provide),
+		fx.Provide(zap.NewNop),
+		fx.Provide(func() trace.Tracer {
+			return noop.NewTracerProvider().Tracer("")
+		}),
+		fx.Provide(db.Provide),
+		fx.Provide(
+			fx.Annotate(denylist.Provide, fx.As(new(denylist.Denylist))),
+		),
+		fx.Populate(&database, &denylistStore),
+	)
+	suite.app.RequireStart()
+
+	suite.DB = database
+	suite.Store = denylistStore
+}
+
+func (suite *MongoDenylistSuite) TearDownSuite() {
+	_, err := suite.DB.Collection(denylist.Collection).DeleteMany(context.Background(), bson.D{})
+	suite.Require().NoError(err)
+
+	suite.app.RequireStop()
+}
+
+func TestMongoDenylistSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(MongoDenylistSuite))
+}
+
+type MemoryDenylistSuite struct {
+	CommonDenylistSuite
+}
+
+func (suite *MemoryDenylistSuite) SetupSuite() {
+	var denylistStore denylist.Denylist
+
+	app := fxtest.New(
+		suite.T(),
+		fx.Provide(
+			fx.Annotate(
+				denylist.NewMemoryDenylist,
+				fx.As(new(denylist.Denylist)),
+			),
+		),
+		fx.Populate(&denylistStore),
+	)
+	defer app.RequireStart().RequireStop()
+
+	suite.Store = denylistStore
+}
+
+func TestMemoryDenylistSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(MemoryDenylistSuite))
+}
@@ -0,0 +1,77 @@
+package denylist
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/1995parham-teaching/fandogh/internal/model"
+)
+
+type MemoryDenylist struct {
+	lock  sync.RWMutex
+	store map[string]model.Revocation
+}
+
+func NewMemoryDenylist() *MemoryDenylist {
+	return &MemoryDenylist{
+		lock:  sync.RWMutex{},
+		store: make(map[string]model.Revocation),
+	}
+}
+
+func (m *MemoryDenylist) Revoke(_ context.Context, id string, expiresAt time.Time) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.prune()
+
+	m.store[id] = model.Revocation{
+		ID:        id,
+		Subject:   "",
+		RevokedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	}
+
+	return nil
+}
+
+func (m *MemoryDenylist) RevokeSubject(_ context.Context, subject string, expiresAt time.Time) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.prune()
+
+	m.store[subjectPrefix+subject] = model.Revocation{
+		ID:        subjectPrefix + subject,
+		Subject:   subject,
+		RevokedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	}
+
+	return nil
+}
+
+func (m *MemoryDenylist) Revoked(_ context.Context, id string, subject string, issuedAt time.Time) (bool, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	var revocations []model.Revocation
+
+	for _, key := range []string{id, subjectPrefix + subject} {
+		if r, ok := m.store[key]; ok {
+			revocations = append(revocations, r)
+		}
+	}
+
+	return revoked(revocations, id, issuedAt), nil
+}
+
+// prune removes the expired revocations, same as mongodb TTL index.
+func (m *MemoryDenylist) prune() {
+	for key, r := range m.store {
+		if r.ExpiresAt.Before(time.Now()) {
+			delete(m.store, key)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package denylist
+
+import (
+	"github.com/1995parham-teaching/fandogh/internal/migration"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Migrations of the denylist collection.
+func Migrations() []migration.Migration {
+	return []migration.Migration{
+		// revocations are kept only until the revoked access tokens expire.
+		migration.Indexes(202610180004, "denylist expiration", Collection, mongo.IndexModel{
+			Keys:    bson.M{"expires_at": migration.Ascending},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		}),
+	}
+}
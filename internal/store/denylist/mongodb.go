@@ -0,0 +1,114 @@
+package denylist
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/1995parham-teaching/fandogh/internal/model"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MongoDenylist communicate with revocations collection in MongoDB.
+// Expired revocations are removed by the TTL index on expires_at.
+type MongoDenylist struct {
+	DB     *mongo.Database
+	Tracer trace.Tracer
+}
+
+// Collection is a name of the MongoDB collection for revoked tokens.
+const Collection = "revocations"
+
+// NewMongoDenylist creates new Denylist store.
+func NewMongoDenylist(db *mongo.Database, tracer trace.Tracer) *MongoDenylist {
+	return &MongoDenylist{
+		DB:     db,
+		Tracer: tracer,
+	}
+}
+
+// Provide creates new Denylist store for dependency injection.
+func Provide(db *mongo.Database, tracer trace.Tracer) *MongoDenylist {
+	return NewMongoDenylist(db, tracer)
+}
+
+// Revoke saves the revocation of the given access token.
+func (s *MongoDenylist) Revoke(ctx context.Context, id string, expiresAt time.Time) error {
+	ctx, span := s.Tracer.Start(ctx, "store.denylist.revoke")
+	defer span.End()
+
+	err := s.upsert(ctx, model.Revocation{
+		ID:        id,
+		Subject:   "",
+		RevokedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		span.RecordError(err)
+
+		return err
+	}
+
+	return nil
+}
+
+// RevokeSubject saves the revocation of the given user access tokens, revoking a user again moves its revocation time.
+func (s *MongoDenylist) RevokeSubject(ctx context.Context, subject string, expiresAt time.Time) error {
+	ctx, span := s.Tracer.Start(ctx, "store.denylist.revoke_subject")
+	defer span.End()
+
+	err := s.upsert(ctx, model.Revocation{
+		ID:        subjectPrefix + subject,
+		Subject:   subject,
+		RevokedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		span.RecordError(err)
+
+		return err
+	}
+
+	return nil
+}
+
+// Revoked looks up the revocations of the given access token and its user.
+func (s *MongoDenylist) Revoked(ctx context.Context, id string, subject string, issuedAt time.Time) (bool, error) {
+	ctx, span := s.Tracer.Start(ctx, "store.denylist.revoked")
+	defer span.End()
+
+	cursor, err := s.DB.Collection(Collection).Find(ctx, bson.M{
+		"_id": bson.M{"$in": bson.A{id, subjectPrefix + subject}},
+	})
+	if err != nil {
+		span.RecordError(err)
+
+		return false, fmt.Errorf("mongodb find failed: %w", err)
+	}
+
+	defer func() { _ = cursor.Close(ctx) }()
+
+	var revocations []model.Revocation
+
+	if err := cursor.All(ctx, &revocations); err != nil {
+		span.RecordError(err)
+
+		return false, fmt.Errorf("mongodb cursor decode failed: %w", err)
+	}
+
+	return revoked(revocations, id, issuedAt), nil
+}
+
+func (s *MongoDenylist) upsert(ctx context.Context, r model.Revocation) error {
+	_, err := s.DB.Collection(Collection).ReplaceOne(ctx, bson.M{
+		"_id": r.ID,
+	}, r, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("mongodb replace failed: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,8 @@
+package home
+
+// Config of the home store, the cursor secret signs the list cursors so clients cannot forge them.
+// Uploads is the number of photos of a request which are processed and uploaded in parallel.
+type Config struct {
+	CursorSecret string `koanf:"cursor_secret"`
+	Uploads      int    `koanf:"uploads"`
+}
@@ -0,0 +1,142 @@
+package home
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/1995parham-teaching/fandogh/internal/model"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var (
+	// ErrInvalidCursor indicates that the cursor is malformed, forged or is issued for another sort.
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrCursorWithSkip indicates that both of the cursor and skip are given.
+	ErrCursorWithSkip = errors.New("cursor cannot be used with skip")
+	// ErrEmptyCursorSecret indicates that the cursor secret is not configured.
+	ErrEmptyCursorSecret = errors.New("cursor secret is required")
+)
+
+// cursor points to the last home of a page by its sort key and id.
+type cursor struct {
+	Field      SortField `json:"f"`
+	Descending bool      `json:"d,omitempty"`
+	Value      int       `json:"v,omitempty"`
+	ID         string    `json:"id"`
+}
+
+// Cursors encodes and decodes opaque cursors which are signed with HMAC-SHA256.
+type Cursors struct {
+	secret []byte
+}
+
+// NewCursors creates new cursor codec.
+func NewCursors(cfg Config) (Cursors, error) {
+	if cfg.CursorSecret == "" {
+		return Cursors{secret: nil}, ErrEmptyCursorSecret
+	}
+
+	return Cursors{secret: []byte(cfg.CursorSecret)}, nil
+}
+
+func (c Cursors) sign(payload string) string {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(payload))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (c Cursors) encode(cur cursor) (string, error) {
+	b, err := json.Marshal(cur)
+	if err != nil {
+		return "", fmt.Errorf("cursor encoding failed: %w", err)
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(b)
+
+	return payload + "." + c.sign(payload), nil
+}
+
+// decode verifies the cursor signature and that it is issued for the given sort.
+func (c Cursors) decode(value string, sort Sort) (cursor, error) {
+	var cur cursor
+
+	payload, signature, ok := strings.Cut(value, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(c.sign(payload))) {
+		return cur, ErrInvalidCursor
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return cur, ErrInvalidCursor
+	}
+
+	if err := json.Unmarshal(b, &cur); err != nil {
+		return cur, ErrInvalidCursor
+	}
+
+	if cur.Field != sort.field() || cur.Descending != sort.Descending {
+		return cur, ErrInvalidCursor
+	}
+
+	return cur, nil
+}
+
+// after returns the MongoDB query for the homes which come after the cursor in its sort.
+func (cur cursor) after() bson.M {
+	op := "$gt"
+	if cur.Descending {
+		op = "$lt"
+	}
+
+	if cur.Field == SortCreation {
+		return bson.M{"_id": bson.M{op: cur.ID}}
+	}
+
+	return bson.M{"$or": bson.A{
+		bson.M{string(cur.Field): bson.M{op: cur.Value}},
+		bson.M{string(cur.Field): cur.Value, "_id": bson.M{op: cur.ID}},
+	}}
+}
+
+// page creates the list result from the fetched homes, which has one more home than the limit
+// when there is a next page.
+func (c Cursors) page(homes []model.Home, total int64, query Query) (ListResult, error) {
+	result := ListResult{
+		Homes:      homes,
+		Total:      total,
+		Skip:       query.Skip,
+		Limit:      query.Limit,
+		NextCursor: "",
+	}
+
+	if int64(len(homes)) <= query.Limit {
+		return result, nil
+	}
+
+	result.Homes = homes[:query.Limit]
+	if len(result.Homes) == 0 {
+		return result, nil
+	}
+
+	last := result.Homes[len(result.Homes)-1]
+
+	next, err := c.encode(cursor{
+		Field:      query.Sort.field(),
+		Descending: query.Sort.Descending,
+		Value:      query.Sort.value(last),
+		ID:         last.ID,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.NextCursor = next
+
+	return result, nil
+}
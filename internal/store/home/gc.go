@@ -0,0 +1,159 @@
+package home
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/1995parham-teaching/fandogh/internal/fs"
+	"github.com/1995parham-teaching/fandogh/internal/model"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// ErrNegativeGrace indicates a grace period which makes the objects of the homes which are being created old enough.
+var ErrNegativeGrace = errors.New("grace period must not be negative")
+
+// Reference is an object which is referenced by a photo of a home.
+type Reference struct {
+	Home  string
+	Photo string
+	Key   string
+}
+
+// Garbage is the result of a garbage collection. Orphans are the objects which are not referenced by any home
+// and are older than the grace period, Recent is the number of the other unreferenced objects, e.g. photos of
+// the homes which are being created or the direct uploads which are not confirmed yet.
+// Missing are the references to the objects which do not exist.
+type Garbage struct {
+	Objects int
+	Orphans []fs.Entry
+	Recent  int
+	Deleted int
+	Missing []Reference
+}
+
+// references returns the references of the given homes by their keys.
+func references(homes []model.Home) map[string]Reference {
+	refs := make(map[string]Reference)
+
+	for _, home := range homes {
+		for name := range home.Photos {
+			for _, key := range photoKeys(home, name) {
+				refs[key] = Reference{Home: home.ID, Photo: name, Key: key}
+			}
+		}
+	}
+
+	return refs
+}
+
+// collect finds the objects which are not in the given references and removes the ones which are older than
+// the grace period unless it is a dry run. The references must be read before the listing, so the objects of the
+// homes which are created during the collection are not older than the grace period.
+func (o objects) collect(
+	ctx context.Context, refs map[string]Reference, grace time.Duration, dryRun bool,
+) (Garbage, error) {
+	// nolint: exhaustruct
+	garbage := Garbage{Orphans: make([]fs.Entry, 0), Missing: make([]Reference, 0)}
+
+	if grace < 0 {
+		return garbage, fmt.Errorf("%w: %s", ErrNegativeGrace, grace)
+	}
+
+	if err := o.Storage.Bucket(ctx, Bucket); err != nil {
+		return garbage, fmt.Errorf("bucket creation/checking failed: %w", err)
+	}
+
+	deadline := time.Now().Add(-grace)
+	found := make(map[string]bool, len(refs))
+
+	for entry, err := range o.Storage.List(ctx, Bucket, "") {
+		if err != nil {
+			return garbage, fmt.Errorf("object listing failed: %w", err)
+		}
+
+		garbage.Objects++
+
+		switch _, ok := refs[entry.Key]; {
+		case ok:
+			found[entry.Key] = true
+		case entry.LastModified.After(deadline):
+			garbage.Recent++
+		default:
+			garbage.Orphans = append(garbage.Orphans, entry)
+		}
+	}
+
+	for key, ref := range refs {
+		if !found[key] {
+			garbage.Missing = append(garbage.Missing, ref)
+		}
+	}
+
+	slices.SortFunc(garbage.Missing, func(a Reference, b Reference) int {
+		return cmp.Or(cmp.Compare(a.Home, b.Home), cmp.Compare(a.Photo, b.Photo), cmp.Compare(a.Key, b.Key))
+	})
+
+	if dryRun {
+		return garbage, nil
+	}
+
+	for _, orphan := range garbage.Orphans {
+		if err := o.Storage.Delete(ctx, Bucket, orphan.Key); err != nil {
+			return garbage, fmt.Errorf("orphan deletion failed: %w", err)
+		}
+
+		garbage.Deleted++
+	}
+
+	return garbage, nil
+}
+
+// Collect reconciles the bucket with the homes, it reports the homes which reference the missing objects and
+// removes the objects which are not referenced by any home after the grace period. Nothing is removed on dry runs.
+func (s *MongoHome) Collect(ctx context.Context, grace time.Duration, dryRun bool) (Garbage, error) {
+	ctx, span := s.Tracer.Start(ctx, "store.home.collect")
+	defer span.End()
+
+	cursor, err := s.DB.Collection(Collection).Find(
+		ctx, bson.M{}, options.Find().SetProjection(bson.M{"photos": 1, "thumbnails": 1}),
+	)
+	if err != nil {
+		span.RecordError(err)
+
+		return Garbage{}, fmt.Errorf("mongodb failed: %w", err)
+	}
+
+	var homes []model.Home
+
+	if err := cursor.All(ctx, &homes); err != nil {
+		span.RecordError(err)
+
+		return Garbage{}, fmt.Errorf("mongodb cursor decode failed: %w", err)
+	}
+
+	garbage, err := s.collect(ctx, references(homes), grace, dryRun)
+	if err != nil {
+		span.RecordError(err)
+
+		return garbage, err
+	}
+
+	return garbage, nil
+}
+
+// Collect reconciles the storage with the homes like MongoHome.Collect.
+func (m *MemoryHome) Collect(ctx context.Context, grace time.Duration, dryRun bool) (Garbage, error) {
+	m.lock.RLock()
+
+	refs := references(slices.Collect(maps.Values(m.store)))
+
+	m.lock.RUnlock()
+
+	return m.collect(ctx, refs, grace, dryRun)
+}
@@ -7,17 +7,116 @@ import (
 )
 
 // ListResult contains paginated list of homes with total count.
+// NextCursor points to the next page and it is empty on the last page.
 type ListResult struct {
-	Homes []model.Home `json:"homes"`
-	Total int64        `json:"total"`
-	Skip  int64        `json:"skip"`
-	Limit int64        `json:"limit"`
+	Homes      []model.Home `json:"homes"`
+	Total      int64        `json:"total"`
+	Skip       int64        `json:"skip"`
+	Limit      int64        `json:"limit"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+// Filter restricts the listed homes, nil fields and empty location are not applied.
+// Location matches any home which its location contains the given value case-insensitively.
+type Filter struct {
+	MinPrice           *int
+	MaxPrice           *int
+	MinSecurityDeposit *int
+	MaxSecurityDeposit *int
+	MinRooms           *int
+	MinBathrooms       *int
+	MinPeoples         *int
+	Bed                *model.Bed
+	Location           string
+	Smoking            *bool
+	Guest              *bool
+	Pet                *bool
+	BillsIncluded      *bool
+}
+
+// SortField is a field which homes can be sorted by, each of them has an index together with the id.
+type SortField string
+
+const (
+	SortPrice           SortField = "price"
+	SortSecurityDeposit SortField = "security_deposit"
+	SortRooms           SortField = "rooms"
+	// SortCreation sorts by the id which is an object id, so it is in the creation order.
+	SortCreation SortField = "_id"
+)
+
+// Sort orders the listed homes. Homes with the same value are ordered by their id, so the pages are stable.
+// Zero value sorts homes in the creation order.
+type Sort struct {
+	Field      SortField
+	Descending bool
+}
+
+// field returns the sort field, the zero value sorts by the id.
+func (s Sort) field() SortField {
+	if s.Field == "" {
+		return SortCreation
+	}
+
+	return s.Field
+}
+
+// value returns the sort key of the given home.
+func (s Sort) value(home model.Home) int {
+	switch s.field() {
+	case SortPrice:
+		return home.Price
+	case SortSecurityDeposit:
+		return home.SecurityDeposit
+	case SortRooms:
+		return home.Rooms
+	case SortCreation:
+		return 0
+	}
+
+	return 0
+}
+
+// Query selects a page of the homes matching the filter in the given order. The page starts after the cursor
+// when it is given, otherwise it starts after skipping the given number of homes.
+type Query struct {
+	Filter Filter
+	Sort   Sort
+	Cursor string
+	Skip   int64
+	Limit  int64
+}
+
+// Patch contains the home fields to change, nil fields remain unchanged.
+type Patch struct {
+	Title           *string
+	Location        *string
+	Description     *string
+	Peoples         *int
+	Room            *string
+	Bed             *model.Bed
+	Rooms           *int
+	Bathrooms       *int
+	Smoking         *bool
+	Guest           *bool
+	Pet             *bool
+	BillsIncluded   *bool
+	Contract        *string
+	SecurityDeposit *int
+	Price           *int
 }
 
 // Home stores the home model into the database and S3. we use S3-compatible storage for storing the image files of each home.
 type Home interface {
 	Set(ctx context.Context, home *model.Home, photos []model.Photo) error
 	Get(ctx context.Context, id string) (model.Home, error)
-	List(ctx context.Context, skip, limit int64) (ListResult, error)
-	Update(ctx context.Context, id string, home model.Home) error
+	List(ctx context.Context, query Query) (ListResult, error)
+	Update(ctx context.Context, id string, version int64, home model.Home) error
+	Patch(ctx context.Context, id string, version int64, patch Patch) (model.Home, error)
+	AddPhotos(ctx context.Context, id string, version int64, photos []model.Photo) (model.Home, error)
+	DeletePhoto(ctx context.Context, id string, version int64, name string) (model.Home, error)
+	OrderPhotos(ctx context.Context, id string, version int64, order []string) (model.Home, error)
+	SetCover(ctx context.Context, id string, version int64, name string) (model.Home, error)
+	ConfirmPhoto(ctx context.Context, id string, version int64, name string, key string) (model.Home, error)
+	Delete(ctx context.Context, id string) error
 }
@@ -1,8 +1,15 @@
 package home_test
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"image"
+	"image/png"
+	iofs "io/fs"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/suite"
 	"go.mongodb.org/mongo-driver/v2/bson"
@@ -16,6 +23,8 @@ import (
 	"github.com/1995parham-teaching/fandogh/internal/config"
 	"github.com/1995parham-teaching/fandogh/internal/db"
 	"github.com/1995parham-teaching/fandogh/internal/fs"
+	"github.com/1995parham-teaching/fandogh/internal/imaging"
+	"github.com/1995parham-teaching/fandogh/internal/migration"
 	"github.com/1995parham-teaching/fandogh/internal/model"
 	"github.com/1995parham-teaching/fandogh/internal/store/home"
 )
@@ -26,6 +35,15 @@ type CommonHomeSuite struct {
 	Store home.Home
 }
 
+// pixel returns a PNG image with a single pixel.
+func pixel() []byte {
+	var buf bytes.Buffer
+
+	_ = png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 1, 1)))
+
+	return buf.Bytes()
+}
+
 func (suite *CommonHomeSuite) TestNoID() {
 	require := suite.Require()
 
@@ -64,13 +82,18 @@ func (suite *CommonHomeSuite) TestSetGet() {
 				Contract:        "contract_type",
 				SecurityDeposit: 0,
 				Photos:          nil,
+				Thumbnails:      nil,
+				PhotoOrder:      nil,
+				Cover:           "",
 				Price:           0,
+				Version:         0,
 			},
 			photos: []model.Photo{
 				{
 					Name:        "1.png",
 					ContentType: "image/png",
-					Content:     []byte{'1', '2', '3'},
+					Content:     bytes.NewReader(pixel()),
+					Size:        int64(len(pixel())),
 				},
 			},
 			expectedSetErr: nil,
@@ -96,13 +119,18 @@ func (suite *CommonHomeSuite) TestSetGet() {
 				Contract:        "contract_type",
 				SecurityDeposit: 0,
 				Photos:          nil,
+				Thumbnails:      nil,
+				PhotoOrder:      nil,
+				Cover:           "",
 				Price:           0,
+				Version:         0,
 			},
 			photos: []model.Photo{
 				{
 					Name:        "1.png",
 					ContentType: "image/png",
-					Content:     []byte{'1', '2', '3'},
+					Content:     bytes.NewReader(pixel()),
+					Size:        int64(len(pixel())),
 				},
 			},
 			expectedSetErr: home.ErrIDNotEmpty,
@@ -119,8 +147,9 @@ func (suite *CommonHomeSuite) TestSetGet() {
 
 				require.NotNil(c.home.Photos)
 
-				for _, key := range c.home.Photos {
+				for name, key := range c.home.Photos {
 					require.NotEmpty(key)
+					require.NotEmpty(c.home.Thumbnails[name])
 				}
 
 				home, err := suite.Store.Get(context.Background(), c.home.ID)
@@ -134,16 +163,352 @@ func (suite *CommonHomeSuite) TestSetGet() {
 	}
 }
 
+func (suite *CommonHomeSuite) TestDelete() {
+	require := suite.Require()
+
+	h := model.Home{
+		ID:              "",
+		Title:           "127.0.0.1",
+		Owner:           "parham.alvani@gmail.com",
+		Location:        "Iran, Tehran",
+		Description:     "Home Sweet Home",
+		Peoples:         4,
+		Room:            "room_type",
+		Bed:             model.Single,
+		Rooms:           1,
+		Bathrooms:       1,
+		Smoking:         false,
+		Guest:           false,
+		Pet:             false,
+		BillsIncluded:   true,
+		Contract:        "contract_type",
+		SecurityDeposit: 0,
+		Photos:          nil,
+		Thumbnails:      nil,
+		PhotoOrder:      nil,
+		Cover:           "",
+		Price:           0,
+		Version:         0,
+	}
+
+	require.NoError(suite.Store.Set(context.Background(), &h, []model.Photo{
+		{
+			Name:        "1.png",
+			ContentType: "image/png",
+			Content:     bytes.NewReader(pixel()),
+			Size:        int64(len(pixel())),
+		},
+	}))
+
+	require.NoError(suite.Store.Delete(context.Background(), h.ID))
+
+	_, err := suite.Store.Get(context.Background(), h.ID)
+	require.Equal(home.ErrIDNotFound, err)
+
+	require.Equal(home.ErrIDNotFound, suite.Store.Delete(context.Background(), h.ID))
+}
+
+func (suite *CommonHomeSuite) TestList() {
+	require := suite.Require()
+
+	const location = "Iran, Shiraz"
+
+	for _, price := range []int{100, 200, 300} {
+		h := model.Home{
+			ID:              "",
+			Title:           "127.0.0.1",
+			Owner:           "parham.alvani@gmail.com",
+			Location:        location,
+			Description:     "Home Sweet Home",
+			Peoples:         price / 100,
+			Room:            "room_type",
+			Bed:             model.Double,
+			Rooms:           2,
+			Bathrooms:       1,
+			Smoking:         price == 300,
+			Guest:           false,
+			Pet:             false,
+			BillsIncluded:   true,
+			Contract:        "contract_type",
+			SecurityDeposit: 0,
+			Photos:          nil,
+			Thumbnails:      nil,
+			PhotoOrder:      nil,
+			Cover:           "",
+			Price:           price,
+			Version:         0,
+		}
+
+		require.NoError(suite.Store.Set(context.Background(), &h, nil))
+	}
+
+	minPrice := 150
+	smoking := false
+
+	// nolint: exhaustruct
+	result, err := suite.Store.List(context.Background(), home.Query{
+		Filter: home.Filter{
+			MinPrice: &minPrice,
+			Location: "shiraz",
+			Smoking:  &smoking,
+		},
+		Limit: 10,
+	})
+	require.NoError(err)
+	require.Equal(int64(1), result.Total)
+	require.Len(result.Homes, 1)
+	require.Equal(200, result.Homes[0].Price)
+	require.Empty(result.NextCursor)
+
+	// nolint: exhaustruct
+	query := home.Query{
+		Filter: home.Filter{Location: location},
+		Sort:   home.Sort{Field: home.SortPrice, Descending: true},
+		Limit:  2,
+	}
+
+	result, err = suite.Store.List(context.Background(), query)
+	require.NoError(err)
+	require.Equal(int64(3), result.Total)
+	require.Len(result.Homes, 2)
+	require.Equal(300, result.Homes[0].Price)
+	require.Equal(200, result.Homes[1].Price)
+	require.NotEmpty(result.NextCursor)
+
+	query.Cursor = result.NextCursor
+
+	result, err = suite.Store.List(context.Background(), query)
+	require.NoError(err)
+	require.Len(result.Homes, 1)
+	require.Equal(100, result.Homes[0].Price)
+	require.Empty(result.NextCursor)
+
+	// cursors are valid only for the sort they are issued for.
+	query.Sort.Descending = false
+
+	_, err = suite.Store.List(context.Background(), query)
+	require.ErrorIs(err, home.ErrInvalidCursor)
+
+	query.Cursor += "forged"
+
+	_, err = suite.Store.List(context.Background(), query)
+	require.ErrorIs(err, home.ErrInvalidCursor)
+}
+
+func (suite *CommonHomeSuite) TestPatch() {
+	require := suite.Require()
+
+	h := model.Home{
+		ID:              "",
+		Title:           "127.0.0.1",
+		Owner:           "parham.alvani@gmail.com",
+		Location:        "Iran, Tehran",
+		Description:     "Home Sweet Home",
+		Peoples:         4,
+		Room:            "room_type",
+		Bed:             model.Single,
+		Rooms:           1,
+		Bathrooms:       1,
+		Smoking:         false,
+		Guest:           false,
+		Pet:             false,
+		BillsIncluded:   true,
+		Contract:        "contract_type",
+		SecurityDeposit: 100,
+		Photos:          nil,
+		Thumbnails:      nil,
+		PhotoOrder:      nil,
+		Cover:           "",
+		Price:           500,
+		Version:         0,
+	}
+
+	require.NoError(suite.Store.Set(context.Background(), &h, nil))
+
+	price := 900
+	bed := model.Double
+
+	require.Equal(int64(1), h.Version)
+
+	// nolint: exhaustruct
+	patched, err := suite.Store.Patch(context.Background(), h.ID, h.Version, home.Patch{
+		Price: &price,
+		Bed:   &bed,
+	})
+	require.NoError(err)
+
+	h.Price = price
+	h.Bed = bed
+	h.Version = 2
+	require.Equal(h, patched)
+
+	stored, err := suite.Store.Get(context.Background(), h.ID)
+	require.NoError(err)
+	require.Equal(h, stored)
+
+	// changes based on the previous version are rejected.
+	// nolint: exhaustruct
+	_, err = suite.Store.Patch(context.Background(), h.ID, 1, home.Patch{Price: &price})
+	require.Equal(home.ErrVersionMismatch, err)
+
+	require.Equal(home.ErrVersionMismatch, suite.Store.Update(context.Background(), h.ID, 1, h))
+
+	require.NoError(suite.Store.Update(context.Background(), h.ID, h.Version, h))
+
+	stored, err = suite.Store.Get(context.Background(), h.ID)
+	require.NoError(err)
+	require.Equal(int64(3), stored.Version)
+
+	// nolint: exhaustruct
+	_, err = suite.Store.Patch(context.Background(), "invalid_id", 1, home.Patch{Price: &price})
+	require.Equal(home.ErrIDNotFound, err)
+}
+
+func (suite *CommonHomeSuite) TestPhotos() {
+	require := suite.Require()
+
+	h := model.Home{
+		ID:              "",
+		Title:           "127.0.0.1",
+		Owner:           "parham.alvani@gmail.com",
+		Location:        "Iran, Tehran",
+		Description:     "Home Sweet Home",
+		Peoples:         4,
+		Room:            "room_type",
+		Bed:             model.Single,
+		Rooms:           1,
+		Bathrooms:       1,
+		Smoking:         false,
+		Guest:           false,
+		Pet:             false,
+		BillsIncluded:   true,
+		Contract:        "contract_type",
+		SecurityDeposit: 100,
+		Photos:          nil,
+		Thumbnails:      nil,
+		PhotoOrder:      nil,
+		Cover:           "",
+		Price:           500,
+		Version:         0,
+	}
+
+	photo := func(name string) model.Photo {
+		return model.Photo{
+			Name:        name,
+			ContentType: "image/png",
+			Content:     bytes.NewReader(pixel()),
+			Size:        int64(len(pixel())),
+		}
+	}
+
+	require.NoError(suite.Store.Set(context.Background(), &h, []model.Photo{photo("1.png")}))
+	require.Equal([]string{"1.png"}, h.PhotoOrder)
+	require.Equal("1.png", h.Cover)
+
+	h, err := suite.Store.AddPhotos(context.Background(), h.ID, h.Version, []model.Photo{photo("2.png"), photo("3.png")})
+	require.NoError(err)
+	require.Equal([]string{"1.png", "2.png", "3.png"}, h.PhotoOrder)
+	require.Len(h.Photos, 3)
+
+	require.Len(h.Thumbnails, 3)
+
+	for _, key := range h.Photos {
+		parsed, err := fs.Parse(key)
+		require.NoError(err)
+		require.Equal(fs.KindPhoto, parsed.Kind)
+		require.Equal(h.ID, parsed.Home)
+	}
+
+	_, err = suite.Store.AddPhotos(context.Background(), h.ID, h.Version, []model.Photo{photo("2.png")})
+	require.Equal(home.ErrPhotoExists, err)
+
+	_, err = suite.Store.AddPhotos(context.Background(), h.ID, h.Version, []model.Photo{{
+		Name:        "4.png",
+		ContentType: "text/plain",
+		Content:     bytes.NewReader([]byte{'1', '2', '3'}),
+		Size:        3,
+	}})
+	require.ErrorIs(err, imaging.ErrNotImage)
+
+	_, err = suite.Store.OrderPhotos(context.Background(), h.ID, h.Version, []string{"3.png", "1.png"})
+	require.Equal(home.ErrInvalidPhotoOrder, err)
+
+	h, err = suite.Store.OrderPhotos(context.Background(), h.ID, h.Version, []string{"3.png", "1.png", "2.png"})
+	require.NoError(err)
+	require.Equal([]string{"3.png", "1.png", "2.png"}, h.PhotoOrder)
+
+	h, err = suite.Store.SetCover(context.Background(), h.ID, h.Version, "2.png")
+	require.NoError(err)
+	require.Equal("2.png", h.Cover)
+
+	_, err = suite.Store.SetCover(context.Background(), h.ID, h.Version, "4.png")
+	require.Equal(home.ErrPhotoNotFound, err)
+
+	// removing the cover makes the first photo the cover.
+	h, err = suite.Store.DeletePhoto(context.Background(), h.ID, h.Version, "2.png")
+	require.NoError(err)
+	require.Equal([]string{"3.png", "1.png"}, h.PhotoOrder)
+	require.Equal("3.png", h.Cover)
+	require.NotContains(h.Thumbnails, "2.png")
+
+	_, err = suite.Store.DeletePhoto(context.Background(), h.ID, 1, "3.png")
+	require.Equal(home.ErrVersionMismatch, err)
+
+	stored, err := suite.Store.Get(context.Background(), h.ID)
+	require.NoError(err)
+	require.Equal(h, stored)
+}
+
+// failing returns a home with three photos which the second one is not an image.
+func failing() (model.Home, []model.Photo) {
+	photos := make([]model.Photo, 0)
+
+	for _, name := range []string{"1.png", "2.png", "3.png"} {
+		content := pixel()
+		if name == "2.png" {
+			content = []byte("not an image")
+		}
+
+		photos = append(photos, model.Photo{
+			Name:        name,
+			ContentType: "image/png",
+			Content:     bytes.NewReader(content),
+			Size:        int64(len(content)),
+		})
+	}
+
+	// nolint: exhaustruct
+	return model.Home{Title: "127.0.0.1", Owner: "parham.alvani@gmail.com"}, photos
+}
+
+func (suite *CommonHomeSuite) TestSetPhotoError() {
+	require := suite.Require()
+
+	h, photos := failing()
+
+	err := suite.Store.Set(context.Background(), &h, photos)
+	require.ErrorIs(err, imaging.ErrNotImage)
+
+	photoErr, ok := errors.AsType[*home.PhotoError](err)
+	require.True(ok)
+	require.Equal("2.png", photoErr.Name)
+
+	require.Empty(h.ID)
+	require.Nil(h.Photos)
+}
+
 type MongoHomeSuite struct {
 	CommonHomeSuite
 
-	DB  *mongo.Database
-	app *fxtest.App
+	DB      *mongo.Database
+	storage fs.Storage
+	app     *fxtest.App
 }
 
 func (suite *MongoHomeSuite) SetupSuite() {
 	var (
 		database  *mongo.Database
+		storage   fs.Storage
 		homeStore home.Home
 	)
 
@@ -156,14 +521,16 @@ func (suite *MongoHomeSuite) SetupSuite() {
 		}),
 		fx.Provide(db.Provide),
 		fx.Provide(fs.Provide),
+		fx.Provide(imaging.Provide),
 		fx.Provide(
 			fx.Annotate(home.Provide, fx.As(new(home.Home))),
 		),
-		fx.Populate(&database, &homeStore),
+		fx.Populate(&database, &storage, &homeStore),
 	)
 	suite.app.RequireStart()
 
 	suite.DB = database
+	suite.storage = storage
 	suite.Store = homeStore
 }
 
@@ -174,7 +541,345 @@ func (suite *MongoHomeSuite) TearDownSuite() {
 	suite.app.RequireStop()
 }
 
+// TestUnversioned changes a home which is stored before versioning, so it has no version field.
+func (suite *MongoHomeSuite) TestUnversioned() {
+	require := suite.Require()
+	ctx := context.Background()
+
+	id := bson.NewObjectID().Hex()
+
+	_, err := suite.DB.Collection(home.Collection).InsertOne(ctx, bson.M{
+		"_id":   id,
+		"owner": "parham.alvani@gmail.com",
+		"title": "127.0.0.1",
+	})
+	require.NoError(err)
+
+	h, err := suite.Store.Get(ctx, id)
+	require.NoError(err)
+	require.Zero(h.Version)
+
+	title := "Home Sweet Home"
+
+	// nolint: exhaustruct
+	h, err = suite.Store.Patch(ctx, id, 0, home.Patch{Title: &title})
+	require.NoError(err)
+	require.Equal(title, h.Title)
+	require.Equal(int64(1), h.Version)
+
+	// the write which matches nothing is never reported as done.
+	h.Title = "127.0.0.1"
+	require.ErrorIs(suite.Store.Update(ctx, id, 0, h), home.ErrVersionMismatch)
+
+	stored, err := suite.Store.Get(ctx, id)
+	require.NoError(err)
+	require.Equal(title, stored.Title)
+
+	require.NoError(suite.Store.Update(ctx, id, 1, h))
+
+	stored, err = suite.Store.Get(ctx, id)
+	require.NoError(err)
+	require.Equal("127.0.0.1", stored.Title)
+	require.Equal(int64(2), stored.Version)
+
+	require.ErrorIs(suite.Store.Update(ctx, bson.NewObjectID().Hex(), 0, h), home.ErrIDNotFound)
+}
+
+// TestMigrateKeys moves a legacy photo of a home which has another one missing on the storage.
+func (suite *MongoHomeSuite) TestMigrateKeys() {
+	require := suite.Require()
+	ctx := context.Background()
+
+	store, ok := suite.Store.(*home.MongoHome)
+	require.True(ok)
+
+	_, err := suite.DB.Collection(migration.Collection).DeleteOne(ctx, bson.M{"_id": home.VersionBackfill})
+	require.NoError(err)
+
+	_, err = store.MigrateKeys(ctx)
+	require.ErrorIs(err, home.ErrNotMigrated)
+
+	_, err = suite.DB.Collection(migration.Collection).InsertOne(ctx, bson.M{"_id": home.VersionBackfill})
+	require.NoError(err)
+
+	defer func() {
+		_, err := suite.DB.Collection(migration.Collection).DeleteOne(ctx, bson.M{"_id": home.VersionBackfill})
+		require.NoError(err)
+	}()
+
+	id := bson.NewObjectID().Hex()
+
+	require.NoError(suite.storage.Put(ctx, home.Bucket, id+"_front", fs.Object{
+		Content:     bytes.NewReader(pixel()),
+		Size:        int64(len(pixel())),
+		ContentType: "image/png",
+		Metadata:    nil,
+	}))
+
+	_, err = suite.DB.Collection(home.Collection).InsertOne(ctx, bson.M{
+		"_id":     id,
+		"owner":   "parham.alvani@gmail.com",
+		"title":   "127.0.0.1",
+		"version": 1,
+		"photos":  bson.M{"front": id + "_front", "back": id + "_back"},
+	})
+	require.NoError(err)
+
+	result, err := store.MigrateKeys(ctx)
+	require.NoError(err)
+	require.GreaterOrEqual(result.Migrated, 1)
+	require.Contains(result.Missing, home.Reference{Home: id, Photo: "back", Key: id + "_back"})
+
+	h, err := suite.Store.Get(ctx, id)
+	require.NoError(err)
+	require.Equal(int64(2), h.Version)
+	require.Equal(id+"_back", h.Photos["back"])
+
+	_, err = fs.Parse(h.Photos["front"])
+	require.NoError(err)
+
+	// the legacy object is removed after the home is updated.
+	_, err = suite.storage.Stat(ctx, home.Bucket, id+"_front")
+	require.ErrorIs(err, fs.ErrNotFound)
+
+	_, err = suite.storage.Stat(ctx, home.Bucket, h.Photos["front"])
+	require.NoError(err)
+}
+
 func TestMongoHomeSuite(t *testing.T) {
 	t.Parallel()
 	suite.Run(t, new(MongoHomeSuite))
 }
+
+type MemoryHomeSuite struct {
+	CommonHomeSuite
+
+	root      string
+	storage   fs.Storage
+	cursors   home.Cursors
+	processor imaging.Processor
+	memory    *home.MemoryHome
+}
+
+func (suite *MemoryHomeSuite) SetupSuite() {
+	require := suite.Require()
+
+	suite.root = suite.T().TempDir()
+
+	storage, err := fs.NewLocal(fs.Config{
+		Driver:        fs.DriverLocal,
+		Endpoint:      "",
+		AccessKey:     "",
+		SecretKey:     "",
+		UseSSL:        false,
+		Region:        "",
+		PresignExpiry: time.Hour,
+		Local: fs.LocalConfig{
+			Root:   suite.root,
+			URL:    "",
+			Secret: "secret",
+		},
+	})
+	require.NoError(err)
+
+	cursors, err := home.NewCursors(home.Config{CursorSecret: "secret"})
+	require.NoError(err)
+
+	processor, err := imaging.NewProcessor(imaging.Config{
+		MaxWidth:   4096,
+		MaxHeight:  4096,
+		Thumbnails: map[string]int{"small": 320},
+	})
+	require.NoError(err)
+
+	suite.storage = storage
+	suite.cursors = cursors
+	suite.processor = processor
+	suite.memory = home.NewMemoryHome(storage, cursors, processor, 2)
+	suite.Store = suite.memory
+}
+
+// objects returns the number of the stored objects.
+func (suite *MemoryHomeSuite) objects() int {
+	count := 0
+
+	err := filepath.WalkDir(filepath.Join(suite.root, home.Bucket, "objects"), func(_ string, d iofs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			count++
+		}
+
+		return err
+	})
+	suite.Require().NoError(err)
+
+	return count
+}
+
+func (suite *MemoryHomeSuite) TestSetRollback() {
+	require := suite.Require()
+
+	h, photos := failing()
+
+	// creates the bucket.
+	require.NoError(suite.Store.Set(context.Background(), &model.Home{}, nil)) // nolint: exhaustruct
+
+	objects := suite.objects()
+
+	require.Error(suite.Store.Set(context.Background(), &h, photos))
+	require.Equal(objects, suite.objects())
+}
+
+func (suite *MemoryHomeSuite) TestCollect() {
+	require := suite.Require()
+
+	ctx := context.Background()
+
+	// nolint: exhaustruct
+	h := model.Home{Title: "127.0.0.1", Owner: "parham.alvani@gmail.com"}
+
+	require.NoError(suite.Store.Set(ctx, &h, []model.Photo{{
+		Name:        "1.png",
+		ContentType: "image/png",
+		Content:     bytes.NewReader(pixel()),
+		Size:        int64(len(pixel())),
+	}}))
+
+	orphan := "homes/" + h.ID + "/uploads/orphan.png"
+
+	require.NoError(suite.storage.Put(ctx, home.Bucket, orphan, fs.Object{
+		Content:     bytes.NewReader(pixel()),
+		Size:        int64(len(pixel())),
+		ContentType: "image/png",
+		Metadata:    nil,
+	}))
+
+	require.NoError(suite.storage.Delete(ctx, home.Bucket, h.Photos["1.png"]))
+
+	keys := func(entries []fs.Entry) []string {
+		keys := make([]string, 0, len(entries))
+
+		for _, entry := range entries {
+			keys = append(keys, entry.Key)
+		}
+
+		return keys
+	}
+
+	// a negative grace period would remove the objects of the homes which are being created.
+	_, err := suite.memory.Collect(ctx, -time.Hour, false)
+	require.ErrorIs(err, home.ErrNegativeGrace)
+
+	// the orphan is in the grace period.
+	garbage, err := suite.memory.Collect(ctx, time.Hour, false)
+	require.NoError(err)
+	require.NotContains(keys(garbage.Orphans), orphan)
+	require.Positive(garbage.Recent)
+	require.Contains(garbage.Missing, home.Reference{Home: h.ID, Photo: "1.png", Key: h.Photos["1.png"]})
+
+	garbage, err = suite.memory.Collect(ctx, 0, true)
+	require.NoError(err)
+	require.Contains(keys(garbage.Orphans), orphan)
+	require.Zero(garbage.Deleted)
+
+	_, err = suite.storage.Stat(ctx, home.Bucket, orphan)
+	require.NoError(err)
+
+	garbage, err = suite.memory.Collect(ctx, 0, false)
+	require.NoError(err)
+	require.Contains(keys(garbage.Orphans), orphan)
+	require.Equal(len(garbage.Orphans), garbage.Deleted)
+
+	_, err = suite.storage.Stat(ctx, home.Bucket, orphan)
+	require.ErrorIs(err, fs.ErrNotFound)
+
+	// the thumbnails of the home are still referenced.
+	for _, key := range h.Thumbnails["1.png"] {
+		_, err = suite.storage.Stat(ctx, home.Bucket, key)
+		require.NoError(err)
+	}
+}
+
+// replaced reports the objects smaller than they are, as an upload which is replaced after its checking.
+type replaced struct {
+	fs.Storage
+}
+
+func (s replaced) Stat(ctx context.Context, bucket string, key string) (fs.Info, error) {
+	info, err := s.Storage.Stat(ctx, bucket, key)
+	info.Size = 1
+
+	return info, err // nolint: wrapcheck
+}
+
+func (suite *MemoryHomeSuite) TestConfirmReplaced() {
+	require := suite.Require()
+
+	ctx := context.Background()
+
+	store := home.NewMemoryHome(replaced{Storage: suite.storage}, suite.cursors, suite.processor, 2)
+
+	// nolint: exhaustruct
+	h := model.Home{Title: "127.0.0.1", Owner: "parham.alvani@gmail.com"}
+	require.NoError(store.Set(ctx, &h, nil))
+
+	upload := fs.GenerateUpload(h.ID, "image/png").String()
+
+	require.NoError(suite.storage.Put(ctx, home.Bucket, upload, fs.Object{
+		Content:     bytes.NewReader(make([]byte, home.MaxPhotoSize+1)),
+		Size:        home.MaxPhotoSize + 1,
+		ContentType: "image/png",
+		Metadata:    nil,
+	}))
+
+	_, err := store.ConfirmPhoto(ctx, h.ID, h.Version, "1.png", upload)
+	require.ErrorIs(err, home.ErrPhotoTooLarge)
+
+	_, err = suite.storage.Stat(ctx, home.Bucket, upload)
+	require.ErrorIs(err, fs.ErrNotFound)
+
+	stored, err := store.Get(ctx, h.ID)
+	require.NoError(err)
+	require.Empty(stored.Photos)
+}
+
+// undeletable fails to delete the objects.
+type undeletable struct {
+	fs.Storage
+}
+
+func (undeletable) Delete(context.Context, string, string) error {
+	return errors.New("object cannot be deleted")
+}
+
+func (suite *MemoryHomeSuite) TestDeleteLeftovers() {
+	require := suite.Require()
+
+	ctx := context.Background()
+
+	store := home.NewMemoryHome(undeletable{Storage: suite.storage}, suite.cursors, suite.processor, 2)
+
+	// nolint: exhaustruct
+	h := model.Home{Title: "127.0.0.1", Owner: "parham.alvani@gmail.com"}
+
+	require.NoError(store.Set(ctx, &h, []model.Photo{{
+		Name:        "1.png",
+		ContentType: "image/png",
+		Content:     bytes.NewReader(pixel()),
+		Size:        int64(len(pixel())),
+	}}))
+
+	// the home is deleted even when its photos are not.
+	require.NoError(store.Delete(ctx, h.ID))
+
+	_, err := store.Get(ctx, h.ID)
+	require.ErrorIs(err, home.ErrIDNotFound)
+
+	// the leftovers are orphans which gc removes.
+	_, err = suite.storage.Stat(ctx, home.Bucket, h.Photos["1.png"])
+	require.NoError(err)
+}
+
+func TestMemoryHomeSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(MemoryHomeSuite))
+}
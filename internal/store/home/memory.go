@@ -0,0 +1,426 @@
+package home
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/1995parham-teaching/fandogh/internal/fs"
+	"github.com/1995parham-teaching/fandogh/internal/imaging"
+	"github.com/1995parham-teaching/fandogh/internal/model"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// MemoryHome keeps the homes in memory for tests and local runs, their photos are stored in the given storage.
+// It has the same ids, pagination and errors as MongoHome.
+type MemoryHome struct {
+	objects
+
+	lock    sync.RWMutex
+	store   map[string]model.Home
+	Cursors Cursors
+}
+
+func NewMemoryHome(storage fs.Storage, cursors Cursors, processor imaging.Processor, uploads int) *MemoryHome {
+	return &MemoryHome{
+		objects: objects{
+			Storage:   storage,
+			Processor: processor,
+			Uploads:   uploads,
+		},
+		lock:    sync.RWMutex{},
+		store:   make(map[string]model.Home),
+		Cursors: cursors,
+	}
+}
+
+// ProvideMemory creates new memory Home store for dependency injection.
+func ProvideMemory(storage fs.Storage, cfg Config, processor imaging.Processor) (*MemoryHome, error) {
+	cursors, err := NewCursors(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewMemoryHome(storage, cursors, processor, cfg.Uploads), nil
+}
+
+// clone copies the given home, so the stored homes are not changed through the returned ones.
+func clone(home model.Home) model.Home {
+	home.Photos = maps.Clone(home.Photos)
+	home.Thumbnails = maps.Clone(home.Thumbnails)
+	home.PhotoOrder = slices.Clone(home.PhotoOrder)
+
+	for name, thumbnails := range home.Thumbnails {
+		home.Thumbnails[name] = maps.Clone(thumbnails)
+	}
+
+	return home
+}
+
+func (m *MemoryHome) Set(ctx context.Context, home *model.Home, photos []model.Photo) error {
+	if home.ID != "" {
+		return ErrIDNotEmpty
+	}
+
+	home.ID = bson.NewObjectID().Hex()
+	home.Version = 1
+
+	if _, err := m.add(ctx, home, photos); err != nil {
+		home.ID, home.Version = "", 0
+
+		return err
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.store[home.ID] = clone(*home)
+
+	return nil
+}
+
+func (m *MemoryHome) Get(_ context.Context, id string) (model.Home, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	home, ok := m.store[id]
+	if !ok {
+		return model.Home{}, ErrIDNotFound
+	}
+
+	return clone(home), nil
+}
+
+func (m *MemoryHome) List(_ context.Context, query Query) (ListResult, error) {
+	if query.Cursor != "" && query.Skip != 0 {
+		return ListResult{}, ErrCursorWithSkip
+	}
+
+	var (
+		cur cursor
+		err error
+	)
+
+	if query.Cursor != "" {
+		cur, err = m.Cursors.decode(query.Cursor, query.Sort)
+		if err != nil {
+			return ListResult{}, err
+		}
+	}
+
+	m.lock.RLock()
+
+	homes := make([]model.Home, 0)
+
+	for _, home := range m.store {
+		if query.Filter.match(home) {
+			homes = append(homes, clone(home))
+		}
+	}
+
+	m.lock.RUnlock()
+
+	total := int64(len(homes))
+
+	slices.SortFunc(homes, query.Sort.compare)
+
+	if query.Cursor != "" {
+		homes = slices.DeleteFunc(homes, func(home model.Home) bool {
+			return !cur.follows(home)
+		})
+	}
+
+	homes = homes[min(query.Skip, int64(len(homes))):]
+	// one more home is kept to find out whether there is a next page.
+	homes = homes[:min(query.Limit+1, int64(len(homes)))]
+
+	return m.Cursors.page(homes, total, query)
+}
+
+// match reports whether the given home passes the filter.
+func (f Filter) match(home model.Home) bool {
+	between := func(value int, lower *int, upper *int) bool {
+		return (lower == nil || value >= *lower) && (upper == nil || value <= *upper)
+	}
+
+	is := func(value bool, expected *bool) bool {
+		return expected == nil || value == *expected
+	}
+
+	return between(home.Price, f.MinPrice, f.MaxPrice) &&
+		between(home.SecurityDeposit, f.MinSecurityDeposit, f.MaxSecurityDeposit) &&
+		between(home.Rooms, f.MinRooms, nil) &&
+		between(home.Bathrooms, f.MinBathrooms, nil) &&
+		between(home.Peoples, f.MinPeoples, nil) &&
+		(f.Bed == nil || home.Bed == *f.Bed) &&
+		strings.Contains(strings.ToLower(home.Location), strings.ToLower(f.Location)) &&
+		is(home.Smoking, f.Smoking) &&
+		is(home.Guest, f.Guest) &&
+		is(home.Pet, f.Pet) &&
+		is(home.BillsIncluded, f.BillsIncluded)
+}
+
+// compare orders the given homes by the sort with the id as the tie-breaker.
+func (s Sort) compare(a model.Home, b model.Home) int {
+	c := cmp.Or(cmp.Compare(s.value(a), s.value(b)), strings.Compare(a.ID, b.ID))
+	if s.Descending {
+		return -c
+	}
+
+	return c
+}
+
+// follows reports whether the given home comes after the cursor in its sort.
+func (cur cursor) follows(home model.Home) bool {
+	sort := Sort{Field: cur.Field, Descending: cur.Descending}
+
+	c := cmp.Or(cmp.Compare(sort.value(home), cur.Value), strings.Compare(home.ID, cur.ID))
+	if cur.Descending {
+		return c < 0
+	}
+
+	return c > 0
+}
+
+func (m *MemoryHome) Update(_ context.Context, id string, version int64, home model.Home) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	stored, err := m.check(id, version)
+	if err != nil {
+		return err
+	}
+
+	stored.Title = home.Title
+	stored.Location = home.Location
+	stored.Description = home.Description
+	stored.Peoples = home.Peoples
+	stored.Room = home.Room
+	stored.Bed = home.Bed
+	stored.Rooms = home.Rooms
+	stored.Bathrooms = home.Bathrooms
+	stored.Smoking = home.Smoking
+	stored.Guest = home.Guest
+	stored.Pet = home.Pet
+	stored.BillsIncluded = home.BillsIncluded
+	stored.Contract = home.Contract
+	stored.SecurityDeposit = home.SecurityDeposit
+	stored.Price = home.Price
+	stored.Version++
+
+	m.store[id] = stored
+
+	return nil
+}
+
+func (m *MemoryHome) Patch(_ context.Context, id string, version int64, patch Patch) (model.Home, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	home, err := m.check(id, version)
+	if err != nil {
+		return clone(home), err
+	}
+
+	if len(patch.fields()) == 0 {
+		return clone(home), nil
+	}
+
+	patch.apply(&home)
+	home.Version++
+
+	m.store[id] = home
+
+	return clone(home), nil
+}
+
+// apply changes the given fields of the patch on the home.
+func (p Patch) apply(home *model.Home) {
+	assign(&home.Title, p.Title)
+	assign(&home.Location, p.Location)
+	assign(&home.Description, p.Description)
+	assign(&home.Peoples, p.Peoples)
+	assign(&home.Room, p.Room)
+	assign(&home.Bed, p.Bed)
+	assign(&home.Rooms, p.Rooms)
+	assign(&home.Bathrooms, p.Bathrooms)
+	assign(&home.Smoking, p.Smoking)
+	assign(&home.Guest, p.Guest)
+	assign(&home.Pet, p.Pet)
+	assign(&home.BillsIncluded, p.BillsIncluded)
+	assign(&home.Contract, p.Contract)
+	assign(&home.SecurityDeposit, p.SecurityDeposit)
+	assign(&home.Price, p.Price)
+}
+
+// assign sets the field when the value is given.
+func assign[T any](field *T, value *T) {
+	if value != nil {
+		*field = *value
+	}
+}
+
+// check returns the stored home of the given id when it is still in the given version, the lock must be held.
+func (m *MemoryHome) check(id string, version int64) (model.Home, error) {
+	home, ok := m.store[id]
+	if !ok {
+		return model.Home{}, ErrIDNotFound
+	}
+
+	if home.Version != version {
+		return home, ErrVersionMismatch
+	}
+
+	return home, nil
+}
+
+// current returns a copy of the home of the given id when it is still in the given version.
+func (m *MemoryHome) current(id string, version int64) (model.Home, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	home, err := m.check(id, version)
+
+	return clone(home), err
+}
+
+// savePhotos stores the photos, their order and the cover of the given home when it is still in the given version.
+func (m *MemoryHome) savePhotos(home *model.Home, version int64) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	stored, err := m.check(home.ID, version)
+	if err != nil {
+		return err
+	}
+
+	stored.Photos = maps.Clone(home.Photos)
+	stored.Thumbnails = clone(*home).Thumbnails
+	stored.PhotoOrder = slices.Clone(home.PhotoOrder)
+	stored.Cover = home.Cover
+	stored.Version = version + 1
+
+	m.store[home.ID] = stored
+
+	home.Version = version + 1
+
+	return nil
+}
+
+func (m *MemoryHome) Delete(ctx context.Context, id string) error {
+	m.lock.Lock()
+
+	home, ok := m.store[id]
+	delete(m.store, id)
+
+	m.lock.Unlock()
+
+	if !ok {
+		return ErrIDNotFound
+	}
+
+	m.purge(ctx, home)
+
+	return nil
+}
+
+func (m *MemoryHome) AddPhotos(ctx context.Context, id string, version int64, photos []model.Photo) (model.Home, error) {
+	home, err := m.current(id, version)
+	if err != nil {
+		return home, err
+	}
+
+	keys, err := m.add(ctx, &home, photos)
+	if err != nil {
+		return home, err
+	}
+
+	if err := m.savePhotos(&home, version); err != nil {
+		// the uploaded photos are not referenced by the home.
+		m.remove(ctx, keys...)
+
+		return home, err
+	}
+
+	return home, nil
+}
+
+func (m *MemoryHome) DeletePhoto(ctx context.Context, id string, version int64, name string) (model.Home, error) {
+	home, err := m.current(id, version)
+	if err != nil {
+		return home, err
+	}
+
+	keys, err := detach(&home, name)
+	if err != nil {
+		return home, err
+	}
+
+	if err := m.savePhotos(&home, version); err != nil {
+		return home, err
+	}
+
+	m.remove(ctx, keys...)
+
+	return home, nil
+}
+
+func (m *MemoryHome) OrderPhotos(_ context.Context, id string, version int64, order []string) (model.Home, error) {
+	home, err := m.current(id, version)
+	if err != nil {
+		return home, err
+	}
+
+	if err := reorder(&home, order); err != nil {
+		return home, err
+	}
+
+	if err := m.savePhotos(&home, version); err != nil {
+		return home, err
+	}
+
+	return home, nil
+}
+
+func (m *MemoryHome) SetCover(_ context.Context, id string, version int64, name string) (model.Home, error) {
+	home, err := m.current(id, version)
+	if err != nil {
+		return home, err
+	}
+
+	if err := cover(&home, name); err != nil {
+		return home, err
+	}
+
+	if err := m.savePhotos(&home, version); err != nil {
+		return home, err
+	}
+
+	return home, nil
+}
+
+func (m *MemoryHome) ConfirmPhoto(ctx context.Context, id string, version int64, name string, key string) (model.Home, error) {
+	home, err := m.current(id, version)
+	if err != nil {
+		return home, err
+	}
+
+	if err := m.confirm(ctx, &home, name, key); err != nil {
+		return home, err
+	}
+
+	if err := m.savePhotos(&home, version); err != nil {
+		// the upload is kept, so it can be confirmed again with the current version.
+		m.remove(ctx, photoKeys(home, name)...)
+
+		return home, err
+	}
+
+	// the upload is replaced by the processed photo.
+	m.remove(ctx, key)
+
+	return home, nil
+}
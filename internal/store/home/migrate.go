@@ -0,0 +1,167 @@
+package home
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+
+	"github.com/1995parham-teaching/fandogh/internal/fs"
+	"github.com/1995parham-teaching/fandogh/internal/migration"
+	"github.com/1995parham-teaching/fandogh/internal/model"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var ErrNotMigrated = errors.New("version backfill migration must be applied before, run migrate up")
+
+// KeyMigration is the result of a key migration. Missing are the legacy objects which do not exist,
+// their photos and thumbnails keep their legacy keys and the other ones of their homes are migrated.
+type KeyMigration struct {
+	Migrated int
+	Missing  []Reference
+}
+
+// MigrateKeys copies the photos and thumbnails which have the legacy keys (<home>_<name>) to the structured keys.
+// Each home is updated only when it is not changed during its migration, then its legacy objects are removed.
+// Homes which are changed can be migrated by running it again. The homes must have their versions, so it refuses
+// to run before the version backfill migration.
+func (s *MongoHome) MigrateKeys(ctx context.Context) (KeyMigration, error) {
+	ctx, span := s.Tracer.Start(ctx, "store.home.migrate_keys")
+	defer span.End()
+
+	result := KeyMigration{Migrated: 0, Missing: make([]Reference, 0)}
+
+	applied, err := migration.Applied(ctx, s.DB, VersionBackfill)
+	if err != nil {
+		span.RecordError(err)
+
+		return result, err
+	}
+
+	if !applied {
+		return result, ErrNotMigrated
+	}
+
+	cursor, err := s.DB.Collection(Collection).Find(ctx, bson.M{})
+	if err != nil {
+		span.RecordError(err)
+
+		return result, fmt.Errorf("mongodb failed: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var home model.Home
+
+		if err := cursor.Decode(&home); err != nil {
+			span.RecordError(err)
+
+			return result, fmt.Errorf("mongodb failed: %w", err)
+		}
+
+		ok, missing, err := s.migrateHome(ctx, home)
+		if err != nil {
+			span.RecordError(err)
+
+			return result, fmt.Errorf("home %s: %w", home.ID, err)
+		}
+
+		result.Missing = append(result.Missing, missing...)
+
+		if ok {
+			result.Migrated++
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		span.RecordError(err)
+
+		return result, fmt.Errorf("mongodb failed: %w", err)
+	}
+
+	return result, nil
+}
+
+// migrateHome copies the legacy objects of the given home and returns true when the home is updated.
+// The legacy objects which do not exist are skipped and returned.
+// nolint: cyclop, funlen
+func (s *MongoHome) migrateHome(ctx context.Context, home model.Home) (bool, []Reference, error) {
+	legacy := make([]string, 0)
+	copied := make([]string, 0)
+	missing := make([]Reference, 0)
+
+	for name, key := range home.Photos {
+		if _, err := fs.Parse(key); err == nil {
+			continue
+		}
+
+		info, err := s.Storage.Stat(ctx, Bucket, key)
+		if errors.Is(err, fs.ErrNotFound) {
+			missing = append(missing, Reference{Home: home.ID, Photo: name, Key: key})
+
+			continue
+		}
+
+		if err != nil {
+			s.remove(ctx, copied...)
+
+			return false, nil, fmt.Errorf("photo %s: %w", name, err)
+		}
+
+		photo := fs.Generate(home.ID, info.ContentType)
+
+		if err := s.Storage.Copy(ctx, Bucket, key, photo.String(), info.ContentType, fs.Metadata(name)); err != nil {
+			s.remove(ctx, copied...)
+
+			return false, nil, err
+		}
+
+		legacy = append(legacy, key)
+		copied = append(copied, photo.String())
+		home.Photos[name] = photo.String()
+
+		thumbnails := maps.Clone(home.Thumbnails[name])
+
+		for size, key := range thumbnails {
+			thumbnail := photo.Thumbnail(size).String()
+
+			// the copy does not tell the missing objects apart, so they are checked before.
+			if _, err := s.Storage.Stat(ctx, Bucket, key); errors.Is(err, fs.ErrNotFound) {
+				missing = append(missing, Reference{Home: home.ID, Photo: name, Key: key})
+
+				continue
+			}
+
+			// thumbnails have the same content type as their photo.
+			err := s.Storage.Copy(ctx, Bucket, key, thumbnail, info.ContentType, fs.Metadata(name))
+			if err != nil {
+				s.remove(ctx, copied...)
+
+				return false, nil, err
+			}
+
+			legacy = append(legacy, key)
+			copied = append(copied, thumbnail)
+			thumbnails[size] = thumbnail
+		}
+
+		if thumbnails != nil {
+			home.Thumbnails[name] = thumbnails
+		}
+	}
+
+	if len(legacy) == 0 {
+		return false, missing, nil
+	}
+
+	// savePhotos fails unless it has updated the home, so the legacy objects are not referenced anymore.
+	if err := s.savePhotos(ctx, &home, home.Version); err != nil {
+		s.remove(ctx, copied...)
+
+		return false, nil, err
+	}
+
+	s.remove(ctx, legacy...)
+
+	return true, missing, nil
+}
@@ -0,0 +1,51 @@
+package home
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/1995parham-teaching/fandogh/internal/migration"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// VersionBackfill is the migration which gives the homes created before versioning their first version.
+const VersionBackfill = 202610180006
+
+// Migrations of the homes collection.
+func Migrations() []migration.Migration {
+	// sorted home listings use the id as the tie-breaker, so each sort field has a compound index with it.
+	sorts := make([]mongo.IndexModel, 0)
+
+	for _, field := range []SortField{SortPrice, SortSecurityDeposit, SortRooms} {
+		sorts = append(sorts, mongo.IndexModel{
+			Keys:    bson.D{{Key: string(field), Value: migration.Ascending}, {Key: "_id", Value: migration.Ascending}},
+			Options: nil,
+		})
+	}
+
+	return []migration.Migration{
+		migration.Indexes(202610180005, "home sort fields", Collection, sorts...),
+		{
+			Version:     VersionBackfill,
+			Description: "homes created before versioning start from the first version",
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				if _, err := db.Collection(Collection).UpdateMany(
+					ctx,
+					bson.M{"version": bson.M{"$exists": false}},
+					bson.M{"$set": bson.M{"version": 1}},
+				); err != nil {
+					return fmt.Errorf("mongodb update failed: %w", err)
+				}
+
+				return nil
+			},
+			// the versions cannot be told apart from the versions of the changed homes.
+			Down: nil,
+		},
+		migration.Indexes(202610180007, "home owners", Collection, mongo.IndexModel{
+			Keys:    bson.D{{Key: "owner", Value: migration.Ascending}, {Key: "price", Value: migration.Ascending}},
+			Options: nil,
+		}),
+	}
+}
@@ -1,15 +1,14 @@
 package home
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"regexp"
 
 	"github.com/1995parham-teaching/fandogh/internal/fs"
+	"github.com/1995parham-teaching/fandogh/internal/imaging"
 	"github.com/1995parham-teaching/fandogh/internal/model"
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
@@ -17,15 +16,18 @@ import (
 )
 
 var (
-	ErrIDNotFound = errors.New("home id does not exist")
-	ErrIDNotEmpty = errors.New("home id must be empty")
+	ErrIDNotFound      = errors.New("home id does not exist")
+	ErrIDNotEmpty      = errors.New("home id must be empty")
+	ErrVersionMismatch = errors.New("home is modified since the given version")
 )
 
 // MongoHome communicate with homes collection in MongoDB.
 type MongoHome struct {
-	DB     *mongo.Database
-	S3     *s3.Client
-	Tracer trace.Tracer
+	objects
+
+	DB      *mongo.Database
+	Cursors Cursors
+	Tracer  trace.Tracer
 }
 
 const (
@@ -37,31 +39,39 @@ const (
 )
 
 // NewMongoHome creates new Home store.
-func NewMongoHome(db *mongo.Database, client *s3.Client, tracer trace.Tracer) *MongoHome {
+func NewMongoHome(
+	db *mongo.Database, storage fs.Storage, cursors Cursors, processor imaging.Processor, uploads int, tracer trace.Tracer,
+) *MongoHome {
 	return &MongoHome{
-		DB:     db,
-		Tracer: tracer,
-		S3:     client,
+		objects: objects{
+			Storage:   storage,
+			Processor: processor,
+			Uploads:   uploads,
+		},
+		DB:      db,
+		Tracer:  tracer,
+		Cursors: cursors,
 	}
 }
 
 // Provide creates new Home store for dependency injection.
-func Provide(db *mongo.Database, client *s3.Client, tracer trace.Tracer) *MongoHome {
-	return NewMongoHome(db, client, tracer)
+func Provide(
+	db *mongo.Database, storage fs.Storage, cfg Config, processor imaging.Processor, tracer trace.Tracer,
+) (*MongoHome, error) {
+	cursors, err := NewCursors(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewMongoHome(db, storage, cursors, processor, cfg.Uploads, tracer), nil
 }
 
-// Set saves given home in database and returns its id.
+// Set uploads the given photos and saves given home in database and returns its id. When it fails,
+// the uploaded photos are removed and the home is not changed.
 func (s *MongoHome) Set(ctx context.Context, home *model.Home, photos []model.Photo) error {
 	ctx, span := s.Tracer.Start(ctx, "store.home.set")
 	defer span.End()
 
-	err := fs.Bucket(ctx, s.S3, Bucket)
-	if err != nil {
-		span.RecordError(ErrIDNotEmpty)
-
-		return fmt.Errorf("s3 bucket creation/checking failed: %w", err)
-	}
-
 	if home.ID != "" {
 		span.RecordError(ErrIDNotEmpty)
 
@@ -69,33 +79,23 @@ func (s *MongoHome) Set(ctx context.Context, home *model.Home, photos []model.Ph
 	}
 
 	home.ID = bson.NewObjectID().Hex()
+	home.Version = 1
 
-	if home.Photos == nil {
-		home.Photos = make(map[string]string)
-	}
-
-	for _, photo := range photos {
-		home.Photos[photo.Name] = fs.Generate(home.ID, photo.Name)
+	keys, err := s.add(ctx, home, photos)
+	if err != nil {
+		span.RecordError(err)
 
-		// nolint: exhaustruct
-		_, err = s.S3.PutObject(ctx, &s3.PutObjectInput{
-			Bucket:      aws.String(Bucket),
-			Key:         aws.String(home.Photos[photo.Name]),
-			Body:        bytes.NewReader(photo.Content),
-			ContentType: aws.String(photo.ContentType),
-		})
-		if err != nil {
-			span.RecordError(err)
+		home.ID, home.Version = "", 0
 
-			return fmt.Errorf("s3 object creation failed: %w", err)
-		}
+		return err
 	}
 
-	users := s.DB.Collection(Collection)
-
-	_, err = users.InsertOne(ctx, home)
-	if err != nil {
+	if _, err := s.DB.Collection(Collection).InsertOne(ctx, home); err != nil {
 		span.RecordError(err)
+		// the uploaded photos are not referenced by any home.
+		s.remove(ctx, keys...)
+
+		home.ID, home.Version = "", 0
 
 		return fmt.Errorf("mongodb failed: %w", err)
 	}
@@ -125,27 +125,48 @@ func (s *MongoHome) Get(ctx context.Context, id string) (model.Home, error) {
 		return home, fmt.Errorf("mongodb failed: %w", err)
 	}
 
+	arrange(&home)
+
 	return home, nil
 }
 
-// List retrieves homes with pagination.
-func (s *MongoHome) List(ctx context.Context, skip, limit int64) (ListResult, error) {
+// List retrieves a page of the homes matching the query filter in the query order.
+// nolint: funlen, cyclop
+func (s *MongoHome) List(ctx context.Context, query Query) (ListResult, error) {
 	ctx, span := s.Tracer.Start(ctx, "store.home.list")
 	defer span.End()
 
+	if query.Cursor != "" && query.Skip != 0 {
+		return ListResult{}, ErrCursorWithSkip
+	}
+
 	collection := s.DB.Collection(Collection)
 
-	total, err := collection.CountDocuments(ctx, bson.M{})
+	filter := query.Filter.query()
+
+	total, err := collection.CountDocuments(ctx, filter)
 	if err != nil {
 		span.RecordError(err)
 
 		return ListResult{}, fmt.Errorf("mongodb count failed: %w", err)
 	}
 
+	if query.Cursor != "" {
+		cur, err := s.Cursors.decode(query.Cursor, query.Sort)
+		if err != nil {
+			span.RecordError(err)
+
+			return ListResult{}, err
+		}
+
+		filter = bson.M{"$and": bson.A{filter, cur.after()}}
+	}
+
+	// one more home is fetched to find out whether there is a next page.
 	// nolint: exhaustruct
-	opts := options.Find().SetSkip(skip).SetLimit(limit)
+	opts := options.Find().SetSort(query.Sort.keys()).SetSkip(query.Skip).SetLimit(query.Limit + 1)
 
-	cursor, err := collection.Find(ctx, bson.M{}, opts)
+	cursor, err := collection.Find(ctx, filter, opts)
 	if err != nil {
 		span.RecordError(err)
 
@@ -166,22 +187,86 @@ func (s *MongoHome) List(ctx context.Context, skip, limit int64) (ListResult, er
 		homes = []model.Home{}
 	}
 
-	return ListResult{
-		Homes: homes,
-		Total: total,
-		Skip:  skip,
-		Limit: limit,
-	}, nil
+	for i := range homes {
+		arrange(&homes[i])
+	}
+
+	return s.Cursors.page(homes, total, query)
 }
 
-// Update modifies an existing home by its ID.
-func (s *MongoHome) Update(ctx context.Context, id string, home model.Home) error {
+// query converts the filter into a MongoDB query.
+func (f Filter) query() bson.M {
+	query := bson.M{}
+
+	between := func(field string, lower *int, upper *int) {
+		bounds := bson.M{}
+
+		if lower != nil {
+			bounds["$gte"] = *lower
+		}
+
+		if upper != nil {
+			bounds["$lte"] = *upper
+		}
+
+		if len(bounds) > 0 {
+			query[field] = bounds
+		}
+	}
+
+	between("price", f.MinPrice, f.MaxPrice)
+	between("security_deposit", f.MinSecurityDeposit, f.MaxSecurityDeposit)
+	between("rooms", f.MinRooms, nil)
+	between("bathrooms", f.MinBathrooms, nil)
+	between("peoples", f.MinPeoples, nil)
+
+	if f.Bed != nil {
+		query["bed"] = *f.Bed
+	}
+
+	if f.Location != "" {
+		query["location"] = bson.M{"$regex": regexp.QuoteMeta(f.Location), "$options": "i"}
+	}
+
+	flags := map[string]*bool{
+		"smoking":        f.Smoking,
+		"guest":          f.Guest,
+		"pet":            f.Pet,
+		"bills_included": f.BillsIncluded,
+	}
+
+	for field, value := range flags {
+		if value != nil {
+			query[field] = *value
+		}
+	}
+
+	return query
+}
+
+// keys converts the sort into MongoDB sort keys with the id as the tie-breaker.
+func (s Sort) keys() bson.D {
+	direction := 1
+	if s.Descending {
+		direction = -1
+	}
+
+	if s.field() == SortCreation {
+		return bson.D{{Key: "_id", Value: direction}}
+	}
+
+	return bson.D{{Key: string(s.field()), Value: direction}, {Key: "_id", Value: direction}}
+}
+
+// Update modifies an existing home by its ID when it is still in the given version and increments its version.
+func (s *MongoHome) Update(ctx context.Context, id string, version int64, home model.Home) error {
 	ctx, span := s.Tracer.Start(ctx, "store.home.update")
 	defer span.End()
 
 	collection := s.DB.Collection(Collection)
 
-	result, err := collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+	result, err := collection.UpdateOne(ctx, versioned(id, version), bson.M{
+		"$inc": bson.M{"version": 1},
 		"$set": bson.M{
 			"title":            home.Title,
 			"location":         home.Location,
@@ -207,8 +292,299 @@ func (s *MongoHome) Update(ctx context.Context, id string, home model.Home) erro
 	}
 
 	if result.MatchedCount == 0 {
-		return ErrIDNotFound
+		return s.conflict(ctx, id)
+	}
+
+	return nil
+}
+
+// Patch changes only the given fields of an existing home by its ID when it is still in the given version,
+// increments its version and returns the patched home.
+func (s *MongoHome) Patch(ctx context.Context, id string, version int64, patch Patch) (model.Home, error) {
+	ctx, span := s.Tracer.Start(ctx, "store.home.patch")
+	defer span.End()
+
+	fields := patch.fields()
+	if len(fields) == 0 {
+		return s.check(ctx, id, version)
+	}
+
+	var home model.Home
+
+	err := s.DB.Collection(Collection).FindOneAndUpdate(
+		ctx,
+		versioned(id, version),
+		bson.M{"$set": fields, "$inc": bson.M{"version": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&home)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return home, s.conflict(ctx, id)
+		}
+
+		span.RecordError(err)
+
+		return home, fmt.Errorf("mongodb update failed: %w", err)
+	}
+
+	arrange(&home)
+
+	return home, nil
+}
+
+// versioned matches the home of the given id when it is still in the given version. The homes which are stored
+// before versioning have no version, so they are in the version zero until the version backfill migration.
+func versioned(id string, version int64) bson.M {
+	if version == 0 {
+		return bson.M{"_id": id, "$or": bson.A{bson.M{"version": 0}, bson.M{"version": bson.M{"$exists": false}}}}
+	}
+
+	return bson.M{"_id": id, "version": version}
+}
+
+// conflict returns the reason of a conditional write which matched no home, the home is either removed
+// or changed since it was read. It never returns nil, so a write which did nothing is never reported as done.
+func (s *MongoHome) conflict(ctx context.Context, id string) error {
+	if _, err := s.Get(ctx, id); err != nil {
+		return err
+	}
+
+	return ErrVersionMismatch
+}
+
+// check retrieves the home of the given id and ensures it is still in the given version.
+func (s *MongoHome) check(ctx context.Context, id string, version int64) (model.Home, error) {
+	home, err := s.Get(ctx, id)
+	if err != nil {
+		return home, err
+	}
+
+	if home.Version != version {
+		return home, ErrVersionMismatch
+	}
+
+	return home, nil
+}
+
+// fields returns the given fields of the patch by their MongoDB names.
+func (p Patch) fields() bson.M {
+	fields := bson.M{}
+
+	set := func(name string, value any, ok bool) {
+		if ok {
+			fields[name] = value
+		}
+	}
+
+	set("title", p.Title, p.Title != nil)
+	set("location", p.Location, p.Location != nil)
+	set("description", p.Description, p.Description != nil)
+	set("peoples", p.Peoples, p.Peoples != nil)
+	set("room", p.Room, p.Room != nil)
+	set("bed", p.Bed, p.Bed != nil)
+	set("rooms", p.Rooms, p.Rooms != nil)
+	set("bathrooms", p.Bathrooms, p.Bathrooms != nil)
+	set("smoking", p.Smoking, p.Smoking != nil)
+	set("guest", p.Guest, p.Guest != nil)
+	set("pet", p.Pet, p.Pet != nil)
+	set("bills_included", p.BillsIncluded, p.BillsIncluded != nil)
+	set("contract", p.Contract, p.Contract != nil)
+	set("security_deposit", p.SecurityDeposit, p.SecurityDeposit != nil)
+	set("price", p.Price, p.Price != nil)
+
+	return fields
+}
+
+// Delete removes an existing home by its ID with all of its photos, the photos which cannot be removed are left to gc.
+func (s *MongoHome) Delete(ctx context.Context, id string) error {
+	ctx, span := s.Tracer.Start(ctx, "store.home.delete")
+	defer span.End()
+
+	var home model.Home
+
+	err := s.DB.Collection(Collection).FindOneAndDelete(ctx, bson.M{"_id": id}).Decode(&home)
+	if err != nil {
+		span.RecordError(err)
+
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrIDNotFound
+		}
+
+		return fmt.Errorf("mongodb delete failed: %w", err)
+	}
+
+	// the home is deleted, so its objects are not referenced anymore.
+	s.purge(ctx, home)
+
+	return nil
+}
+
+// AddPhotos uploads the given photos and adds them at the end of the photo order of an existing home
+// when it is still in the given version.
+func (s *MongoHome) AddPhotos(ctx context.Context, id string, version int64, photos []model.Photo) (model.Home, error) {
+	ctx, span := s.Tracer.Start(ctx, "store.home.add_photos")
+	defer span.End()
+
+	home, err := s.check(ctx, id, version)
+	if err != nil {
+		span.RecordError(err)
+
+		return home, err
+	}
+
+	keys, err := s.add(ctx, &home, photos)
+	if err != nil {
+		span.RecordError(err)
+
+		return home, err
+	}
+
+	if err := s.savePhotos(ctx, &home, version); err != nil {
+		span.RecordError(err)
+		// the uploaded photos are not referenced by the home.
+		s.remove(ctx, keys...)
+
+		return home, err
+	}
+
+	return home, nil
+}
+
+// DeletePhoto removes the given photo of an existing home when it is still in the given version.
+// When the cover photo is removed, the first photo becomes the cover.
+func (s *MongoHome) DeletePhoto(ctx context.Context, id string, version int64, name string) (model.Home, error) {
+	ctx, span := s.Tracer.Start(ctx, "store.home.delete_photo")
+	defer span.End()
+
+	home, err := s.check(ctx, id, version)
+	if err != nil {
+		span.RecordError(err)
+
+		return home, err
+	}
+
+	keys, err := detach(&home, name)
+	if err != nil {
+		return home, err
+	}
+
+	if err := s.savePhotos(ctx, &home, version); err != nil {
+		span.RecordError(err)
+
+		return home, err
+	}
+
+	s.remove(ctx, keys...)
+
+	return home, nil
+}
+
+// OrderPhotos changes the photo order of an existing home when it is still in the given version.
+// The order must contain the name of each photo exactly once.
+func (s *MongoHome) OrderPhotos(ctx context.Context, id string, version int64, order []string) (model.Home, error) {
+	ctx, span := s.Tracer.Start(ctx, "store.home.order_photos")
+	defer span.End()
+
+	home, err := s.check(ctx, id, version)
+	if err != nil {
+		span.RecordError(err)
+
+		return home, err
+	}
+
+	if err := reorder(&home, order); err != nil {
+		return home, err
+	}
+
+	if err := s.savePhotos(ctx, &home, version); err != nil {
+		span.RecordError(err)
+
+		return home, err
+	}
+
+	return home, nil
+}
+
+// SetCover designates the given photo as the cover of an existing home when it is still in the given version.
+func (s *MongoHome) SetCover(ctx context.Context, id string, version int64, name string) (model.Home, error) {
+	ctx, span := s.Tracer.Start(ctx, "store.home.set_cover")
+	defer span.End()
+
+	home, err := s.check(ctx, id, version)
+	if err != nil {
+		span.RecordError(err)
+
+		return home, err
+	}
+
+	if err := cover(&home, name); err != nil {
+		return home, err
+	}
+
+	if err := s.savePhotos(ctx, &home, version); err != nil {
+		span.RecordError(err)
+
+		return home, err
+	}
+
+	return home, nil
+}
+
+// ConfirmPhoto adds a photo which is uploaded directly to the storage with a presigned URL at the end of the photo
+// order of an existing home when it is still in the given version. The upload is processed like the other photos
+// and then it is removed, uploads which are larger than MaxPhotoSize or cannot be processed are removed too.
+func (s *MongoHome) ConfirmPhoto(ctx context.Context, id string, version int64, name string, key string) (model.Home, error) {
+	ctx, span := s.Tracer.Start(ctx, "store.home.confirm_photo")
+	defer span.End()
+
+	home, err := s.check(ctx, id, version)
+	if err != nil {
+		span.RecordError(err)
+
+		return home, err
+	}
+
+	if err := s.confirm(ctx, &home, name, key); err != nil {
+		span.RecordError(err)
+
+		return home, err
+	}
+
+	if err := s.savePhotos(ctx, &home, version); err != nil {
+		span.RecordError(err)
+		// the upload is kept, so it can be confirmed again with the current version.
+		s.remove(ctx, photoKeys(home, name)...)
+
+		return home, err
+	}
+
+	// the upload is replaced by the processed photo.
+	s.remove(ctx, key)
+
+	return home, nil
+}
+
+// savePhotos stores the photos, their order and the cover of the given home when it is still in the given version.
+// Photo names may contain dots, so the photos are replaced together instead of being updated by their path.
+func (s *MongoHome) savePhotos(ctx context.Context, home *model.Home, version int64) error {
+	result, err := s.DB.Collection(Collection).UpdateOne(ctx, versioned(home.ID, version), bson.M{
+		"$inc": bson.M{"version": 1},
+		"$set": bson.M{
+			"photos":      home.Photos,
+			"thumbnails":  home.Thumbnails,
+			"photo_order": home.PhotoOrder,
+			"cover":       home.Cover,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("mongodb update failed: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return s.conflict(ctx, home.ID)
 	}
 
+	home.Version = version + 1
+
 	return nil
 }
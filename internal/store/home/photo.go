@@ -0,0 +1,359 @@
+package home
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+
+	"github.com/1995parham-teaching/fandogh/internal/fs"
+	"github.com/1995parham-teaching/fandogh/internal/imaging"
+	"github.com/1995parham-teaching/fandogh/internal/model"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/errgroup"
+)
+
+// MaxPhotoSize is the maximum size of each photo in bytes.
+const MaxPhotoSize = 10 << 20
+
+var (
+	ErrPhotoExists       = errors.New("home already has a photo with this name")
+	ErrPhotoNotFound     = errors.New("home photo does not exist")
+	ErrInvalidPhotoOrder = errors.New("photo order must contain each photo of the home exactly once")
+	ErrPhotoNotUploaded  = errors.New("photo is not uploaded for this home")
+	ErrPhotoTooLarge     = errors.New("photo is larger than the upload limit")
+)
+
+// arrange keeps the photo order and cover consistent with the photos. Photos which are missing from the order,
+// e.g. of the homes created before ordering, are appended by their name and the first photo is the default cover.
+func arrange(home *model.Home) {
+	order := make([]string, 0, len(home.Photos))
+
+	for _, name := range home.PhotoOrder {
+		if _, ok := home.Photos[name]; ok && !slices.Contains(order, name) {
+			order = append(order, name)
+		}
+	}
+
+	missing := make([]string, 0)
+
+	for name := range home.Photos {
+		if !slices.Contains(order, name) {
+			missing = append(missing, name)
+		}
+	}
+
+	slices.Sort(missing)
+
+	home.PhotoOrder = append(order, missing...)
+
+	if _, ok := home.Photos[home.Cover]; !ok {
+		home.Cover = ""
+
+		if len(home.PhotoOrder) > 0 {
+			home.Cover = home.PhotoOrder[0]
+		}
+	}
+}
+
+// photoKeys returns the keys of the given photo and its thumbnails.
+func photoKeys(home model.Home, name string) []string {
+	keys := []string{home.Photos[name]}
+
+	for _, key := range home.Thumbnails[name] {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// reorder changes the photo order of the home, the order must be a permutation of the photo names.
+func reorder(home *model.Home, order []string) error {
+	if len(order) != len(home.Photos) {
+		return ErrInvalidPhotoOrder
+	}
+
+	for i, name := range order {
+		if _, ok := home.Photos[name]; !ok || slices.Contains(order[:i], name) {
+			return ErrInvalidPhotoOrder
+		}
+	}
+
+	home.PhotoOrder = slices.Clone(order)
+
+	return nil
+}
+
+// PhotoError reports the photo which cannot be added.
+type PhotoError struct {
+	Name string
+	Err  error
+}
+
+func (e *PhotoError) Error() string {
+	return fmt.Sprintf("photo %s: %s", e.Name, e.Err)
+}
+
+func (e *PhotoError) Unwrap() error {
+	return e.Err
+}
+
+// objects stores the processed photos of the homes with their thumbnails, it is shared by the home stores
+// which only differ in storing the homes. Uploads is the number of photos which are processed and uploaded
+// in parallel.
+type objects struct {
+	Storage   fs.Storage
+	Processor imaging.Processor
+	Uploads   int
+}
+
+// stored is a photo in the storage with its thumbnails.
+type stored struct {
+	Key        string
+	Thumbnails map[string]string
+}
+
+// keys returns the keys of the photo and its thumbnails.
+func (p stored) keys() []string {
+	return append(slices.Collect(maps.Values(p.Thumbnails)), p.Key)
+}
+
+// attach adds the given stored photo to the given home.
+func attach(home *model.Home, name string, photo stored) {
+	if home.Photos == nil {
+		home.Photos = make(map[string]string)
+	}
+
+	if home.Thumbnails == nil {
+		home.Thumbnails = make(map[string]map[string]string)
+	}
+
+	home.Photos[name] = photo.Key
+	home.Thumbnails[name] = photo.Thumbnails
+	home.PhotoOrder = append(home.PhotoOrder, name)
+}
+
+// add uploads the given photos in parallel and adds them at the end of the photo order of the given home, then it
+// returns the keys of the uploaded objects. When a photo cannot be added, the home is not changed, the uploaded
+// objects are removed and the error is a PhotoError of the first failed photo.
+func (o objects) add(ctx context.Context, home *model.Home, photos []model.Photo) ([]string, error) {
+	for i, photo := range photos {
+		if _, ok := home.Photos[photo.Name]; ok || slices.ContainsFunc(photos[:i], func(p model.Photo) bool {
+			return p.Name == photo.Name
+		}) {
+			return nil, ErrPhotoExists
+		}
+	}
+
+	if err := o.Storage.Bucket(ctx, Bucket); err != nil {
+		return nil, fmt.Errorf("bucket creation/checking failed: %w", err)
+	}
+
+	uploaded := make([]stored, len(photos))
+
+	// the other uploads are canceled when a photo fails.
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(max(o.Uploads, 1))
+
+	for i, photo := range photos {
+		g.Go(func() error {
+			p, err := o.put(gctx, home.ID, photo.Name, photo.Content)
+			if err != nil {
+				return &PhotoError{Name: photo.Name, Err: err}
+			}
+
+			uploaded[i] = p
+
+			return nil
+		})
+	}
+
+	err := g.Wait()
+
+	keys := make([]string, 0, len(photos))
+
+	for _, p := range uploaded {
+		if p.Key != "" {
+			keys = append(keys, p.keys()...)
+		}
+	}
+
+	if err != nil {
+		o.remove(ctx, keys...)
+
+		// nolint: wrapcheck
+		return nil, err
+	}
+
+	for i, photo := range photos {
+		attach(home, photo.Name, uploaded[i])
+	}
+
+	arrange(home)
+
+	return keys, nil
+}
+
+// confirm processes the given upload and adds it at the end of the photo order of the given home. Uploads which
+// are larger than MaxPhotoSize or cannot be processed are removed, otherwise the caller removes the upload
+// after saving the home.
+func (o objects) confirm(ctx context.Context, home *model.Home, name string, key string) error {
+	if _, ok := home.Photos[name]; ok {
+		return ErrPhotoExists
+	}
+
+	if upload, err := fs.Parse(key); err != nil || upload.Kind != fs.KindUpload || upload.Home != home.ID {
+		return ErrPhotoNotUploaded
+	}
+
+	info, err := o.Storage.Stat(ctx, Bucket, key)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotFound) {
+			return ErrPhotoNotUploaded
+		}
+
+		return fmt.Errorf("upload checking failed: %w", err)
+	}
+
+	if info.Size > MaxPhotoSize {
+		o.remove(ctx, key)
+
+		return ErrPhotoTooLarge
+	}
+
+	upload, _, err := o.Storage.Get(ctx, Bucket, key)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotFound) {
+			return ErrPhotoNotUploaded
+		}
+
+		return fmt.Errorf("upload reading failed: %w", err)
+	}
+	defer upload.Close()
+
+	// the upload can be replaced after its checking, so its content is limited too.
+	content, err := io.ReadAll(io.LimitReader(upload, MaxPhotoSize+1))
+	if err != nil {
+		return fmt.Errorf("upload reading failed: %w", err)
+	}
+
+	if len(content) > MaxPhotoSize {
+		o.remove(ctx, key)
+
+		return ErrPhotoTooLarge
+	}
+
+	photo, err := o.put(ctx, home.ID, name, bytes.NewReader(content))
+	if err != nil {
+		o.remove(ctx, key)
+
+		return &PhotoError{Name: name, Err: err}
+	}
+
+	attach(home, name, photo)
+	arrange(home)
+
+	return nil
+}
+
+// put processes the given photo of the given home and uploads it with its thumbnails. The uploaded objects are
+// removed when any of them fails.
+func (o objects) put(ctx context.Context, id string, name string, content io.Reader) (stored, error) {
+	img, err := o.Processor.Process(content)
+	if err != nil {
+		return stored{}, fmt.Errorf("processing failed: %w", err)
+	}
+
+	key := fs.Generate(id, img.ContentType)
+
+	if err := o.upload(ctx, key.String(), name, img.Encoded); err != nil {
+		return stored{}, err
+	}
+
+	photo := stored{
+		Key:        key.String(),
+		Thumbnails: make(map[string]string, len(img.Thumbnails)),
+	}
+
+	for size, thumbnail := range img.Thumbnails {
+		thumbnailKey := key.Thumbnail(size).String()
+
+		if err := o.upload(ctx, thumbnailKey, name, thumbnail); err != nil {
+			o.remove(ctx, photo.keys()...)
+
+			return stored{}, err
+		}
+
+		photo.Thumbnails[size] = thumbnailKey
+	}
+
+	return photo, nil
+}
+
+// upload stores the given encoded image of the given photo with the given key.
+func (o objects) upload(ctx context.Context, key string, name string, img imaging.Encoded) error {
+	if err := o.Storage.Put(ctx, Bucket, key, fs.Object{
+		Content:     bytes.NewReader(img.Content),
+		Size:        int64(len(img.Content)),
+		ContentType: img.ContentType,
+		Metadata:    fs.Metadata(name),
+	}); err != nil {
+		return fmt.Errorf("upload failed: %w", err)
+	}
+
+	return nil
+}
+
+// purge removes the objects of all photos of the given home, which is deleted, on a best effort basis.
+// The objects which are not removed are orphans and gc removes them.
+func (o objects) purge(ctx context.Context, home model.Home) {
+	keys := make([]string, 0)
+
+	for name := range home.Photos {
+		keys = append(keys, photoKeys(home, name)...)
+	}
+
+	o.remove(ctx, keys...)
+}
+
+// remove deletes the given photo objects on a best effort basis, because their home does not reference them anymore.
+// They are removed even when the given context is canceled, e.g. when the client disconnects.
+func (o objects) remove(ctx context.Context, keys ...string) {
+	ctx = context.WithoutCancel(ctx)
+
+	for _, key := range keys {
+		if err := o.Storage.Delete(ctx, Bucket, key); err != nil {
+			trace.SpanFromContext(ctx).RecordError(err)
+		}
+	}
+}
+
+// detach removes the given photo from the given home and returns the keys of its objects,
+// which must be removed after saving the home.
+func detach(home *model.Home, name string) ([]string, error) {
+	if _, ok := home.Photos[name]; !ok {
+		return nil, ErrPhotoNotFound
+	}
+
+	keys := photoKeys(*home, name)
+
+	delete(home.Photos, name)
+	delete(home.Thumbnails, name)
+	arrange(home)
+
+	return keys, nil
+}
+
+// cover designates the given photo as the cover of the given home.
+func cover(home *model.Home, name string) error {
+	if _, ok := home.Photos[name]; !ok {
+		return ErrPhotoNotFound
+	}
+
+	home.Cover = name
+
+	return nil
+}
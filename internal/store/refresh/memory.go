@@ -0,0 +1,98 @@
+package refresh
+
+import (
+	"context"
+	"sync"
+
+	"github.com/1995parham-teaching/fandogh/internal/model"
+)
+
+type MemoryRefresh struct {
+	lock  sync.Mutex
+	store map[string]model.RefreshToken
+}
+
+func NewMemoryRefresh() *MemoryRefresh {
+	return &MemoryRefresh{
+		lock:  sync.Mutex{},
+		store: make(map[string]model.RefreshToken),
+	}
+}
+
+func (m *MemoryRefresh) Set(_ context.Context, token *model.RefreshToken) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if _, ok := m.store[token.ID]; ok {
+		return ErrTokenDuplicate
+	}
+
+	m.store[token.ID] = *token
+
+	return nil
+}
+
+func (m *MemoryRefresh) Get(_ context.Context, id string) (model.RefreshToken, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	token, ok := m.store[id]
+	if !ok {
+		return token, ErrTokenNotFound
+	}
+
+	return token, nil
+}
+
+func (m *MemoryRefresh) Rotate(_ context.Context, id string) (model.RefreshToken, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	token, ok := m.store[id]
+	if !ok {
+		return token, ErrTokenNotFound
+	}
+
+	if token.Rotated {
+		m.revokeFamily(token.Family)
+
+		return token, ErrTokenReused
+	}
+
+	token.Rotated = true
+	m.store[id] = token
+
+	return token, check(token)
+}
+
+func (m *MemoryRefresh) RevokeFamily(_ context.Context, family string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.revokeFamily(family)
+
+	return nil
+}
+
+func (m *MemoryRefresh) RevokeUser(_ context.Context, email string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for id, token := range m.store {
+		if token.Email == email {
+			token.Revoked = true
+			m.store[id] = token
+		}
+	}
+
+	return nil
+}
+
+func (m *MemoryRefresh) revokeFamily(family string) {
+	for id, token := range m.store {
+		if token.Family == family {
+			token.Revoked = true
+			m.store[id] = token
+		}
+	}
+}
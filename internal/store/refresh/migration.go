@@ -0,0 +1,25 @@
+package refresh
+
+import (
+	"github.com/1995parham-teaching/fandogh/internal/migration"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Migrations of the refresh tokens collection.
+func Migrations() []migration.Migration {
+	return []migration.Migration{
+		// expired refresh tokens are removed by mongodb and families are revoked together.
+		migration.Indexes(202610180003, "refresh token expiration and families", Collection,
+			mongo.IndexModel{
+				Keys:    bson.M{"expires_at": migration.Ascending},
+				Options: options.Index().SetExpireAfterSeconds(0),
+			},
+			mongo.IndexModel{
+				Keys:    bson.M{"family": migration.Ascending},
+				Options: nil,
+			},
+		),
+	}
+}
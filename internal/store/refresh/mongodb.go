@@ -0,0 +1,152 @@
+package refresh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/1995parham-teaching/fandogh/internal/model"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MongoRefresh communicate with refresh tokens collection in MongoDB.
+type MongoRefresh struct {
+	DB     *mongo.Database
+	Tracer trace.Tracer
+}
+
+// Collection is a name of the MongoDB collection for refresh tokens.
+const Collection = "refresh_tokens"
+
+// NewMongoRefresh creates new Refresh store.
+func NewMongoRefresh(db *mongo.Database, tracer trace.Tracer) *MongoRefresh {
+	return &MongoRefresh{
+		DB:     db,
+		Tracer: tracer,
+	}
+}
+
+// Provide creates new Refresh store for dependency injection.
+func Provide(db *mongo.Database, tracer trace.Tracer) *MongoRefresh {
+	return NewMongoRefresh(db, tracer)
+}
+
+// Set saves given refresh token in database.
+func (s *MongoRefresh) Set(ctx context.Context, token *model.RefreshToken) error {
+	ctx, span := s.Tracer.Start(ctx, "store.refresh.set")
+	defer span.End()
+
+	_, err := s.DB.Collection(Collection).InsertOne(ctx, token)
+	if err != nil {
+		span.RecordError(err)
+
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrTokenDuplicate
+		}
+
+		return fmt.Errorf("mongodb failed: %w", err)
+	}
+
+	return nil
+}
+
+// Get retrieves refresh token of the given id if it exists.
+func (s *MongoRefresh) Get(ctx context.Context, id string) (model.RefreshToken, error) {
+	ctx, span := s.Tracer.Start(ctx, "store.refresh.get")
+	defer span.End()
+
+	var token model.RefreshToken
+
+	err := s.DB.Collection(Collection).FindOne(ctx, bson.M{"_id": id}).Decode(&token)
+	if err != nil {
+		span.RecordError(err)
+
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return token, ErrTokenNotFound
+		}
+
+		return token, fmt.Errorf("mongodb failed: %w", err)
+	}
+
+	return token, nil
+}
+
+// Rotate atomically marks the refresh token as rotated, so concurrent refreshes cannot both succeed.
+func (s *MongoRefresh) Rotate(ctx context.Context, id string) (model.RefreshToken, error) {
+	ctx, span := s.Tracer.Start(ctx, "store.refresh.rotate")
+	defer span.End()
+
+	collection := s.DB.Collection(Collection)
+
+	var token model.RefreshToken
+
+	err := collection.FindOneAndUpdate(ctx, bson.M{
+		"_id":     id,
+		"rotated": false,
+	}, bson.M{
+		"$set": bson.M{"rotated": true},
+	}).Decode(&token)
+	if err == nil {
+		return token, check(token)
+	}
+
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		span.RecordError(err)
+
+		return token, fmt.Errorf("mongodb failed: %w", err)
+	}
+
+	// the token does not exist or it is already rotated.
+	token, err = s.Get(ctx, id)
+	if err != nil {
+		return token, err
+	}
+
+	span.RecordError(ErrTokenReused)
+
+	if err := s.RevokeFamily(ctx, token.Family); err != nil {
+		return token, err
+	}
+
+	return token, ErrTokenReused
+}
+
+// RevokeFamily revokes every refresh token of the given family.
+func (s *MongoRefresh) RevokeFamily(ctx context.Context, family string) error {
+	ctx, span := s.Tracer.Start(ctx, "store.refresh.revoke_family")
+	defer span.End()
+
+	_, err := s.DB.Collection(Collection).UpdateMany(ctx, bson.M{
+		"family": family,
+	}, bson.M{
+		"$set": bson.M{"revoked": true},
+	})
+	if err != nil {
+		span.RecordError(err)
+
+		return fmt.Errorf("mongodb update failed: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeUser revokes every refresh token of the given user.
+func (s *MongoRefresh) RevokeUser(ctx context.Context, email string) error {
+	ctx, span := s.Tracer.Start(ctx, "store.refresh.revoke_user")
+	defer span.End()
+
+	_, err := s.DB.Collection(Collection).UpdateMany(ctx, bson.M{
+		"email": email,
+	}, bson.M{
+		"$set": bson.M{"revoked": true},
+	})
+	if err != nil {
+		span.RecordError(err)
+
+		return fmt.Errorf("mongodb update failed: %w", err)
+	}
+
+	return nil
+}
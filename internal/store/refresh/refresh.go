@@ -0,0 +1,86 @@
+package refresh
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/1995parham-teaching/fandogh/internal/model"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrTokenNotFound indicates that given refresh token does not exist.
+	ErrTokenNotFound = errors.New("refresh token does not exist")
+	// ErrTokenReused indicates that given refresh token is already rotated, so its family is revoked.
+	ErrTokenReused = errors.New("refresh token is already used")
+	// ErrTokenRevoked indicates that given refresh token is revoked.
+	ErrTokenRevoked = errors.New("refresh token is revoked")
+	// ErrTokenExpired indicates that given refresh token is expired.
+	ErrTokenExpired = errors.New("refresh token is expired")
+	// ErrTokenDuplicate indicates that given refresh token exists.
+	ErrTokenDuplicate = errors.New("refresh token exists")
+)
+
+const tokenLength = 32
+
+// Refresh stores refresh tokens and rotates them.
+type Refresh interface {
+	Set(ctx context.Context, token *model.RefreshToken) error
+	Get(ctx context.Context, id string) (model.RefreshToken, error)
+	// Rotate marks the token of the given id as used and returns it. Using a token twice
+	// revokes every token of its family and returns ErrTokenReused.
+	Rotate(ctx context.Context, id string) (model.RefreshToken, error)
+	RevokeFamily(ctx context.Context, family string) error
+	// RevokeUser revokes every refresh token of the given user.
+	RevokeUser(ctx context.Context, email string) error
+}
+
+// New generates a refresh token for the given user. The token joins the given family or starts a new one
+// when family is empty. It returns the opaque token for the client and its record for the store.
+func New(email string, family string, ttl time.Duration) (string, model.RefreshToken, error) {
+	b := make([]byte, tokenLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", model.RefreshToken{}, fmt.Errorf("refresh token generation failed: %w", err)
+	}
+
+	if family == "" {
+		family = uuid.New().String()
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	return token, model.RefreshToken{
+		ID:        Hash(token),
+		Family:    family,
+		Email:     email,
+		Rotated:   false,
+		Revoked:   false,
+		ExpiresAt: time.Now().Add(ttl),
+	}, nil
+}
+
+// Hash returns the id of the given opaque refresh token.
+func Hash(token string) string {
+	h := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(h[:])
+}
+
+// check validates the rotated token.
+func check(token model.RefreshToken) error {
+	if token.Revoked {
+		return ErrTokenRevoked
+	}
+
+	if time.Now().After(token.ExpiresAt) {
+		return ErrTokenExpired
+	}
+
+	return nil
+}
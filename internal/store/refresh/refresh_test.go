@@ -0,0 +1,160 @@
+package refresh_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+
+	"github.com/1995parham-teaching/fandogh/internal/config"
+	"github.com/1995parham-teaching/fandogh/internal/db"
+	"github.com/1995parham-teaching/fandogh/internal/store/refresh"
+)
+
+const email = "parham.alvani@gmail.com"
+
+type CommonRefreshSuite struct {
+	suite.Suite
+
+	Store refresh.Refresh
+}
+
+func (suite *CommonRefreshSuite) TestNotFound() {
+	require := suite.Require()
+
+	_, err := suite.Store.Rotate(context.Background(), refresh.Hash("invalid"))
+	require.Equal(refresh.ErrTokenNotFound, err)
+}
+
+func (suite *CommonRefreshSuite) TestRotate() {
+	require := suite.Require()
+
+	first, record, err := refresh.New(email, "", time.Hour)
+	require.NoError(err)
+	require.Equal(refresh.Hash(first), record.ID)
+	require.NoError(suite.Store.Set(context.Background(), &record))
+
+	token, err := suite.Store.Rotate(context.Background(), refresh.Hash(first))
+	require.NoError(err)
+	require.Equal(email, token.Email)
+
+	second, next, err := refresh.New(email, token.Family, time.Hour)
+	require.NoError(err)
+	require.NoError(suite.Store.Set(context.Background(), &next))
+
+	// reusing the rotated token revokes the whole family.
+	_, err = suite.Store.Rotate(context.Background(), refresh.Hash(first))
+	require.Equal(refresh.ErrTokenReused, err)
+
+	_, err = suite.Store.Rotate(context.Background(), refresh.Hash(second))
+	require.Equal(refresh.ErrTokenRevoked, err)
+}
+
+func (suite *CommonRefreshSuite) TestExpired() {
+	require := suite.Require()
+
+	token, record, err := refresh.New(email, "", -time.Minute)
+	require.NoError(err)
+	require.NoError(suite.Store.Set(context.Background(), &record))
+
+	_, err = suite.Store.Rotate(context.Background(), refresh.Hash(token))
+	require.Equal(refresh.ErrTokenExpired, err)
+}
+
+func (suite *CommonRefreshSuite) TestRevokeUser() {
+	require := suite.Require()
+
+	const other = "elahe.dstn@gmail.com"
+
+	token, record, err := refresh.New(other, "", time.Hour)
+	require.NoError(err)
+	require.NoError(suite.Store.Set(context.Background(), &record))
+
+	require.NoError(suite.Store.RevokeUser(context.Background(), other))
+
+	stored, err := suite.Store.Get(context.Background(), refresh.Hash(token))
+	require.NoError(err)
+	require.True(stored.Revoked)
+
+	_, err = suite.Store.Rotate(context.Background(), refresh.Hash(token))
+	require.Equal(refresh.ErrTokenRevoked, err)
+}
+
+type MongoRefreshSuite struct {
+	CommonRefreshSuite
+
+	DB  *mongo.Database
+	app *fxtest.App
+}
+
+func (suite *MongoRefreshSuite) SetupSuite() {
+	var (
+		database     *mongo.Database
+		refreshStore refresh.Refresh
+	)
+
+	suite.app = fxtest.New(
+		suite.T(),
+		fx.Provide(config.Provide),
+		fx.Provide(zap.NewNop),
+		fx.Provide(func() trace.Tracer {
+			return noop.NewTracerProvider().Tracer("")
+		}),
+		fx.Provide(db.Provide),
+		fx.Provide(
+			fx.Annotate(refresh.Provide, fx.As(new(refresh.Refresh))),
+		),
+		fx.Populate(&database, &refreshStore),
+	)
+	suite.app.RequireStart()
+
+	suite.DB = database
+	suite.Store = refreshStore
+}
+
+func (suite *MongoRefreshSuite) TearDownSuite() {
+	_, err := suite.DB.Collection(refresh.Collection).DeleteMany(context.Background(), bson.D{})
+	suite.Require().NoError(err)
+
+	suite.app.RequireStop()
+}
+
+func TestMongoRefreshSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(MongoRefreshSuite))
+}
+
+type MemoryRefreshSuite struct {
+	CommonRefreshSuite
+}
+
+func (suite *MemoryRefreshSuite) SetupSuite() {
+	var refreshStore refresh.Refresh
+
+	app := fxtest.New(
+		suite.T(),
+		fx.Provide(
+			fx.Annotate(
+				refresh.NewMemoryRefresh,
+				fx.As(new(refresh.Refresh)),
+			),
+		),
+		fx.Populate(&refreshStore),
+	)
+	defer app.RequireStart().RequireStop()
+
+	suite.Store = refreshStore
+}
+
+func TestMemoryRefreshSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(MemoryRefreshSuite))
+}
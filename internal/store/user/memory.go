@@ -2,36 +2,69 @@ package user
 
 import (
 	"context"
+	"fmt"
+	"sync"
 
 	"github.com/1995parham-teaching/fandogh/internal/model"
+	"github.com/1995parham-teaching/fandogh/internal/security"
 )
 
 type MemoryUser struct {
-	store map[string]model.User
+	lock   sync.RWMutex
+	store  map[string]model.User
+	hasher security.Hasher
 }
 
-func NewMemoryUser() *MemoryUser {
+func NewMemoryUser(hasher security.Hasher) *MemoryUser {
 	return &MemoryUser{
-		store: make(map[string]model.User),
+		lock:   sync.RWMutex{},
+		store:  make(map[string]model.User),
+		hasher: hasher,
 	}
 }
 
-func (m MemoryUser) Set(_ context.Context, user *model.User) error {
+func (m *MemoryUser) Set(_ context.Context, user *model.User) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	if _, ok := m.store[user.Email]; ok {
 		return ErrEmailDuplicate
 	}
 
+	hash, err := m.hasher.Hash(user.Password)
+	if err != nil {
+		return fmt.Errorf("password hashing failed: %w", err)
+	}
+
+	record := *user
+	record.Password = hash
+
 	// First user becomes admin
 	if len(m.store) == 0 {
-		user.Admin = true
+		record.Admin = true
 	}
 
-	m.store[user.Email] = *user
+	m.store[user.Email] = record
+	*user = record
 
 	return nil
 }
 
-func (m MemoryUser) Get(_ context.Context, email string) (model.User, error) {
+// Seed stores the given users as they are, their passwords are not hashed, e.g. to load the users which are
+// stored before hashing.
+func (m *MemoryUser) Seed(users ...model.User) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for _, user := range users {
+		m.store[user.Email] = user
+	}
+}
+
+func (m *MemoryUser) Get(_ context.Context, email string) (model.User, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
 	user, ok := m.store[email]
 	if ok {
 		return user, nil
@@ -39,3 +72,31 @@ func (m MemoryUser) Get(_ context.Context, email string) (model.User, error) {
 
 	return user, ErrEmailNotFound
 }
+
+func (m *MemoryUser) Authenticate(ctx context.Context, email string, password string) (model.User, error) {
+	user, err := m.Get(ctx, email)
+	if err != nil {
+		return user, err
+	}
+
+	rehash, err := verify(m.hasher, user, password)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	if !rehash {
+		return user, nil
+	}
+
+	// login must not fail because of the upgrade, the old hash is still valid.
+	if hash, err := m.hasher.Hash(password); err == nil {
+		user.Password = hash
+		user.LegacyPassword = false
+
+		m.lock.Lock()
+		m.store[email] = user
+		m.lock.Unlock()
+	}
+
+	return user, nil
+}
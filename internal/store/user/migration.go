@@ -0,0 +1,31 @@
+package user
+
+import (
+	"context"
+
+	"github.com/1995parham-teaching/fandogh/internal/migration"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Migrations of the users collection.
+func Migrations() []migration.Migration {
+	return []migration.Migration{
+		migration.Indexes(202610180001, "unique user emails", Collection, mongo.IndexModel{
+			Keys:    bson.M{"email": migration.Ascending},
+			Options: options.Index().SetUnique(true),
+		}),
+		{
+			Version:     202610180002,
+			Description: "flag legacy plaintext passwords for upgrade on next login",
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				_, err := MarkLegacyPasswords(ctx, db)
+
+				return err
+			},
+			// the plaintext passwords must not be trusted again.
+			Down: nil,
+		},
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/1995parham-teaching/fandogh/internal/model"
+	"github.com/1995parham-teaching/fandogh/internal/security"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.opentelemetry.io/otel/trace"
@@ -16,11 +17,14 @@ var (
 	ErrEmailNotFound = errors.New("given email does not exist")
 	// ErrEmailDuplicate indicates that given email is exists on database.
 	ErrEmailDuplicate = errors.New("given email exists")
+	// ErrPasswordMismatch indicates that given password does not match the stored one.
+	ErrPasswordMismatch = errors.New("given password does not match")
 )
 
 // MongoUser communicate with users collection in MongoDB.
 type MongoUser struct {
 	DB     *mongo.Database
+	Hasher security.Hasher
 	Tracer trace.Tracer
 }
 
@@ -28,25 +32,37 @@ type MongoUser struct {
 const Collection = "users"
 
 // NewMongoUser creates new User store.
-func NewMongoUser(db *mongo.Database, tracer trace.Tracer) *MongoUser {
+func NewMongoUser(db *mongo.Database, hasher security.Hasher, tracer trace.Tracer) *MongoUser {
 	return &MongoUser{
 		DB:     db,
+		Hasher: hasher,
 		Tracer: tracer,
 	}
 }
 
 // Provide creates new User store for dependency injection.
-func Provide(db *mongo.Database, tracer trace.Tracer) *MongoUser {
-	return NewMongoUser(db, tracer)
+func Provide(db *mongo.Database, hasher security.Hasher, tracer trace.Tracer) *MongoUser {
+	return NewMongoUser(db, hasher, tracer)
 }
 
-// Set saves given user in database. The first registered user becomes admin.
+// Set saves given user in database with its password hashed. The first registered user becomes admin.
 func (s *MongoUser) Set(ctx context.Context, user *model.User) error {
 	ctx, span := s.Tracer.Start(ctx, "store.user.set")
 	defer span.End()
 
 	users := s.DB.Collection(Collection)
 
+	hash, err := s.Hasher.Hash(user.Password)
+	if err != nil {
+		span.RecordError(err)
+
+		return fmt.Errorf("password hashing failed: %w", err)
+	}
+
+	// the given user is changed only when it is stored, so it can be set again with its plaintext password.
+	record := *user
+	record.Password = hash
+
 	// Check if this is the first user - make them admin
 	count, err := users.CountDocuments(ctx, bson.M{})
 	if err != nil {
@@ -56,10 +72,10 @@ func (s *MongoUser) Set(ctx context.Context, user *model.User) error {
 	}
 
 	if count == 0 {
-		user.Admin = true
+		record.Admin = true
 	}
 
-	_, err = users.InsertOne(ctx, user)
+	_, err = users.InsertOne(ctx, record)
 	if err != nil {
 		span.RecordError(err)
 
@@ -70,6 +86,8 @@ func (s *MongoUser) Set(ctx context.Context, user *model.User) error {
 		return fmt.Errorf("mongodb failed: %w", err)
 	}
 
+	*user = record
+
 	return nil
 }
 
@@ -97,3 +115,74 @@ func (s *MongoUser) Get(ctx context.Context, email string) (model.User, error) {
 
 	return user, nil
 }
+
+// Authenticate retrieves user of the given email if the given password matches. Legacy plaintext passwords and
+// hashes created with an outdated configuration are re-hashed transparently.
+func (s *MongoUser) Authenticate(ctx context.Context, email string, password string) (model.User, error) {
+	ctx, span := s.Tracer.Start(ctx, "store.user.authenticate")
+	defer span.End()
+
+	user, err := s.Get(ctx, email)
+	if err != nil {
+		return user, err
+	}
+
+	rehash, err := verify(s.Hasher, user, password)
+	if err != nil {
+		span.RecordError(err)
+
+		return model.User{}, err
+	}
+
+	if !rehash {
+		return user, nil
+	}
+
+	// login must not fail because of the upgrade, the old hash is still valid.
+	hash, err := s.rehash(ctx, email, password)
+	if err != nil {
+		span.RecordError(err)
+
+		return user, nil // nolint: nilerr
+	}
+
+	user.Password = hash
+	user.LegacyPassword = false
+
+	return user, nil
+}
+
+// rehash hashes the given password with the current configuration and replaces the stored one.
+func (s *MongoUser) rehash(ctx context.Context, email string, password string) (string, error) {
+	hash, err := s.Hasher.Hash(password)
+	if err != nil {
+		return "", fmt.Errorf("password hashing failed: %w", err)
+	}
+
+	_, err = s.DB.Collection(Collection).UpdateOne(ctx, bson.M{
+		"email": email,
+	}, bson.M{
+		"$set":   bson.M{"password": hash},
+		"$unset": bson.M{"legacy_password": ""},
+	})
+	if err != nil {
+		return "", fmt.Errorf("mongodb update failed: %w", err)
+	}
+
+	return hash, nil
+}
+
+// MarkLegacyPasswords flags the users that still have a plaintext password, so they are hashed on their next login.
+func MarkLegacyPasswords(ctx context.Context, db *mongo.Database) (int64, error) {
+	result, err := db.Collection(Collection).UpdateMany(ctx, bson.M{
+		"password":        bson.M{"$not": bson.Regex{Pattern: `^\$(argon2id|2[aby])\$`, Options: ""}},
+		"legacy_password": bson.M{"$ne": true},
+	}, bson.M{
+		"$set": bson.M{"legacy_password": true},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("mongodb update failed: %w", err)
+	}
+
+	return result.ModifiedCount, nil
+}
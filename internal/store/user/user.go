@@ -2,12 +2,51 @@ package user
 
 import (
 	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
 
 	"github.com/1995parham-teaching/fandogh/internal/model"
+	"github.com/1995parham-teaching/fandogh/internal/security"
 )
 
-// User stores and retrieves users.
+// User stores and retrieves users. Passwords are hashed on Set and verified by Authenticate,
+// which also upgrades the stored hash when the hashing configuration has changed.
 type User interface {
 	Set(ctx context.Context, user *model.User) error
 	Get(ctx context.Context, email string) (model.User, error)
+	Authenticate(ctx context.Context, email string, password string) (model.User, error)
+}
+
+// verify checks the given password against the stored one and reports whether it must be re-hashed.
+func verify(hasher security.Hasher, user model.User, password string) (bool, error) {
+	if user.LegacyPassword {
+		return verifyLegacy(user.Password, password)
+	}
+
+	err := hasher.Compare(user.Password, password)
+
+	// the plaintext passwords which are stored after flagging the legacy ones are not flagged.
+	if errors.Is(err, security.ErrUnknownHash) {
+		return verifyLegacy(user.Password, password)
+	}
+
+	if errors.Is(err, security.ErrPasswordMismatch) {
+		return false, ErrPasswordMismatch
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("password verification failed: %w", err)
+	}
+
+	return hasher.NeedsRehash(user.Password), nil
+}
+
+// verifyLegacy checks the given password against the stored plaintext one, which must be re-hashed when it matches.
+func verifyLegacy(stored string, password string) (bool, error) {
+	if subtle.ConstantTimeCompare([]byte(stored), []byte(password)) != 1 {
+		return false, ErrPasswordMismatch
+	}
+
+	return true, nil
 }
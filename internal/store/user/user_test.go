@@ -13,10 +13,12 @@ import (
 	"go.uber.org/fx"
 	"go.uber.org/fx/fxtest"
 	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
 
 	"github.com/1995parham-teaching/fandogh/internal/config"
 	"github.com/1995parham-teaching/fandogh/internal/db"
 	"github.com/1995parham-teaching/fandogh/internal/model"
+	"github.com/1995parham-teaching/fandogh/internal/security"
 	"github.com/1995parham-teaching/fandogh/internal/store/user"
 )
 
@@ -71,6 +73,11 @@ func (suite *CommonUserSuite) TestSetGet() {
 			u := c.user
 			require.Equal(c.expectedSetErr, suite.Store.Set(context.Background(), &u))
 
+			// the failed user keeps its plaintext password, so it is not hashed twice on a retry.
+			if c.expectedSetErr != nil {
+				require.Equal(c.user.Password, u.Password)
+			}
+
 			if c.expectedSetErr == nil {
 				user, err := suite.Store.Get(context.Background(), c.user.Email)
 				require.Equal(c.expectedGetErr, err)
@@ -85,6 +92,30 @@ func (suite *CommonUserSuite) TestSetGet() {
 	}
 }
 
+func (suite *CommonUserSuite) TestAuthenticate() {
+	require := suite.Require()
+
+	u := model.User{
+		Name:           "Elahe Dastan",
+		Email:          "elahe.dstn@gmail.com",
+		Password:       "123456",
+		Admin:          false,
+		LegacyPassword: false,
+	}
+	require.NoError(suite.Store.Set(context.Background(), &u))
+	require.NotEqual("123456", u.Password)
+
+	authenticated, err := suite.Store.Authenticate(context.Background(), u.Email, "123456")
+	require.NoError(err)
+	require.Equal(u.Email, authenticated.Email)
+
+	_, err = suite.Store.Authenticate(context.Background(), u.Email, "1234567")
+	require.Equal(user.ErrPasswordMismatch, err)
+
+	_, err = suite.Store.Authenticate(context.Background(), "notexists@gmail.com", "123456")
+	require.Equal(user.ErrEmailNotFound, err)
+}
+
 type MongoUserSuite struct {
 	CommonUserSuite
 
@@ -106,6 +137,7 @@ func (suite *MongoUserSuite) SetupSuite() {
 			return noop.NewTracerProvider().Tracer("")
 		}),
 		fx.Provide(db.Provide),
+		fx.Provide(security.Provide),
 		fx.Provide(
 			fx.Annotate(user.Provide, fx.As(new(user.User))),
 		),
@@ -146,24 +178,140 @@ func TestMongoUserSuite(t *testing.T) {
 
 type MemoryUserSuite struct {
 	CommonUserSuite
+
+	memory *user.MemoryUser
 }
 
 func (suite *MemoryUserSuite) SetupSuite() {
-	var userStore user.User
+	var memory *user.MemoryUser
 
 	app := fxtest.New(
 		suite.T(),
+		fx.Provide(func() (security.Hasher, error) {
+			return security.NewHasher(security.Password{
+				Algorithm:  security.Bcrypt,
+				BcryptCost: bcrypt.MinCost,
+				Argon2:     security.Argon2{Time: 0, Memory: 0, Threads: 0, KeyLength: 0, SaltLength: 0},
+			})
+		}),
 		fx.Provide(
 			fx.Annotate(
 				user.NewMemoryUser,
+				fx.As(fx.Self()),
 				fx.As(new(user.User)),
 			),
 		),
-		fx.Populate(&userStore),
+		fx.Populate(&memory),
 	)
 	defer app.RequireStart().RequireStop()
 
-	suite.Store = userStore
+	suite.memory = memory
+	suite.Store = memory
+}
+
+// TestAuthenticateLegacy logs in with a plaintext password from before hashing, which is hashed on the login.
+func (suite *MemoryUserSuite) TestAuthenticateLegacy() {
+	require := suite.Require()
+
+	ctx := context.Background()
+
+	suite.memory.Seed(model.User{
+		Name:           "Legacy User",
+		Email:          "legacy@gmail.com",
+		Password:       "123456",
+		Admin:          false,
+		LegacyPassword: true,
+	})
+
+	_, err := suite.Store.Authenticate(ctx, "legacy@gmail.com", "1234567")
+	require.Equal(user.ErrPasswordMismatch, err)
+
+	authenticated, err := suite.Store.Authenticate(ctx, "legacy@gmail.com", "123456")
+	require.NoError(err)
+	require.False(authenticated.LegacyPassword)
+	require.True(security.IsHash(authenticated.Password))
+
+	stored, err := suite.Store.Get(ctx, "legacy@gmail.com")
+	require.NoError(err)
+	require.False(stored.LegacyPassword)
+	require.Equal(authenticated.Password, stored.Password)
+
+	// the plaintext password is not accepted as the hash anymore.
+	_, err = suite.Store.Authenticate(ctx, "legacy@gmail.com", stored.Password)
+	require.Equal(user.ErrPasswordMismatch, err)
+
+	_, err = suite.Store.Authenticate(ctx, "legacy@gmail.com", "123456")
+	require.NoError(err)
+}
+
+// TestAuthenticateUnflagged logs in with a plaintext password which is stored after flagging the legacy ones,
+// so it is not flagged, and it is hashed on the login.
+func (suite *MemoryUserSuite) TestAuthenticateUnflagged() {
+	require := suite.Require()
+
+	ctx := context.Background()
+
+	suite.memory.Seed(model.User{
+		Name:           "Unflagged User",
+		Email:          "unflagged@gmail.com",
+		Password:       "123456",
+		Admin:          false,
+		LegacyPassword: false,
+	})
+
+	_, err := suite.Store.Authenticate(ctx, "unflagged@gmail.com", "1234567")
+	require.Equal(user.ErrPasswordMismatch, err)
+
+	authenticated, err := suite.Store.Authenticate(ctx, "unflagged@gmail.com", "123456")
+	require.NoError(err)
+	require.True(security.IsHash(authenticated.Password))
+
+	stored, err := suite.Store.Get(ctx, "unflagged@gmail.com")
+	require.NoError(err)
+	require.Equal(authenticated.Password, stored.Password)
+
+	_, err = suite.Store.Authenticate(ctx, "unflagged@gmail.com", "123456")
+	require.NoError(err)
+}
+
+// TestAuthenticateRehash logs in with a hash of the outdated parameters, which is re-hashed on the login.
+func (suite *MemoryUserSuite) TestAuthenticateRehash() {
+	require := suite.Require()
+
+	ctx := context.Background()
+
+	outdated, err := bcrypt.GenerateFromPassword([]byte("123456"), bcrypt.MinCost+1)
+	require.NoError(err)
+
+	suite.memory.Seed(model.User{
+		Name:           "Raha Dastan",
+		Email:          "raha.dstn@gmail.com",
+		Password:       string(outdated),
+		Admin:          false,
+		LegacyPassword: false,
+	})
+
+	_, err = suite.Store.Authenticate(ctx, "raha.dstn@gmail.com", "1234567")
+	require.Equal(user.ErrPasswordMismatch, err)
+
+	stored, err := suite.Store.Get(ctx, "raha.dstn@gmail.com")
+	require.NoError(err)
+	require.Equal(string(outdated), stored.Password)
+
+	authenticated, err := suite.Store.Authenticate(ctx, "raha.dstn@gmail.com", "123456")
+	require.NoError(err)
+	require.NotEqual(string(outdated), authenticated.Password)
+
+	cost, err := bcrypt.Cost([]byte(authenticated.Password))
+	require.NoError(err)
+	require.Equal(bcrypt.MinCost, cost)
+
+	stored, err = suite.Store.Get(ctx, "raha.dstn@gmail.com")
+	require.NoError(err)
+	require.Equal(authenticated.Password, stored.Password)
+
+	_, err = suite.Store.Authenticate(ctx, "raha.dstn@gmail.com", "123456")
+	require.NoError(err)
 }
 
 func TestMemoryUserSuite(t *testing.T) {